@@ -31,6 +31,15 @@ func New(lg logger.WarnAndError, url, psw string, db int, prefix string) *St {
 	}
 }
 
+func (c *St) Close() error {
+	return c.r.Close()
+}
+
+// Check pings redis, implements health.Checker
+func (c *St) Check(ctx context.Context) error {
+	return c.r.Ping(ctx).Err()
+}
+
 func (c *St) Get(key string) ([]byte, bool, error) {
 	data, err := c.r.Get(c.ctx, c.prefix+key).Bytes()
 	if err == redis.Nil {
@@ -0,0 +1,198 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+// Basic
+
+func (o *BasicAuthCredsSt) Apply(ctx context.Context, req *http.Request) error {
+	req.SetBasicAuth(o.Username, o.Password)
+	return nil
+}
+
+// Bearer
+
+type BearerAuthSt struct {
+	Token string
+}
+
+func (o *BearerAuthSt) Apply(ctx context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+o.Token)
+	return nil
+}
+
+// OAuth2 client credentials
+
+var defaultClientCredentialsOptions = ClientCredentialsOptionsSt{
+	RefreshBefore: 30 * time.Second,
+	Timeout:       15 * time.Second,
+}
+
+type ClientCredentialsOptionsSt struct {
+	Client        *http.Client // default - http.DefaultClient
+	TokenUrl      string
+	ClientId      string
+	ClientSecret  string
+	Scopes        []string
+	Params        url.Values // additional token request params, e.g. audience
+	CredsInBody   bool       // send client_id/client_secret in body instead of basic auth
+	RefreshBefore time.Duration
+	Timeout       time.Duration
+}
+
+func (o *ClientCredentialsOptionsSt) mergeWithDefaults() {
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.RefreshBefore <= 0 {
+		o.RefreshBefore = defaultClientCredentialsOptions.RefreshBefore
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultClientCredentialsOptions.Timeout
+	}
+}
+
+type ClientCredentialsAuthSt struct {
+	opts ClientCredentialsOptionsSt
+	now  func() time.Time
+
+	token     string
+	tokenType string
+	expiresAt time.Time
+	mu        sync.Mutex
+}
+
+type clientCredentialsTokenRepSt struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func NewClientCredentialsAuth(opts ClientCredentialsOptionsSt) *ClientCredentialsAuthSt {
+	opts.mergeWithDefaults()
+
+	return &ClientCredentialsAuthSt{
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+func (o *ClientCredentialsAuthSt) Apply(ctx context.Context, req *http.Request) error {
+	token, tokenType, err := o.GetToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", tokenType+" "+token)
+
+	return nil
+}
+
+func (o *ClientCredentialsAuthSt) Invalidate() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.token = ""
+}
+
+// GetToken returns cached token, the token is refreshed RefreshBefore its expiration
+func (o *ClientCredentialsAuthSt) GetToken(ctx context.Context) (string, string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != "" && (o.expiresAt.IsZero() || o.now().Add(o.opts.RefreshBefore).Before(o.expiresAt)) {
+		return o.token, o.tokenType, nil
+	}
+
+	repObj, err := o.fetchToken(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	o.token = repObj.AccessToken
+	o.tokenType = "Bearer"
+	if repObj.TokenType != "" && !strings.EqualFold(repObj.TokenType, "bearer") {
+		o.tokenType = repObj.TokenType
+	}
+	o.expiresAt = time.Time{}
+	if repObj.ExpiresIn > 0 {
+		o.expiresAt = o.now().Add(time.Duration(repObj.ExpiresIn) * time.Second)
+	}
+
+	return o.token, o.tokenType, nil
+}
+
+func (o *ClientCredentialsAuthSt) fetchToken(ctx context.Context) (*clientCredentialsTokenRepSt, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.opts.Timeout)
+	defer cancel()
+
+	form := url.Values{}
+	for k, v := range o.opts.Params {
+		form[k] = v
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(o.opts.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.opts.Scopes, " "))
+	}
+	if o.opts.CredsInBody {
+		form.Set("client_id", o.opts.ClientId)
+		form.Set("client_secret", o.opts.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.opts.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if !o.opts.CredsInBody {
+		req.SetBasicAuth(url.QueryEscape(o.opts.ClientId), url.QueryEscape(o.opts.ClientSecret))
+	}
+
+	rep, err := o.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rep.Body.Close()
+
+	repBody, err := ioutil.ReadAll(rep.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if rep.StatusCode < 200 || rep.StatusCode > 299 {
+		return nil, dopErrs.ErrWithDesc{
+			Err:  ErrAuthTokenFetch,
+			Desc: "status_code " + strconv.Itoa(rep.StatusCode) + ": " + string(repBody),
+		}
+	}
+
+	repObj := &clientCredentialsTokenRepSt{}
+
+	err = json.Unmarshal(repBody, repObj)
+	if err != nil {
+		return nil, err
+	}
+
+	if repObj.AccessToken == "" {
+		return nil, dopErrs.ErrWithDesc{
+			Err:  ErrAuthTokenFetch,
+			Desc: "empty access_token",
+		}
+	}
+
+	return repObj, nil
+}
@@ -0,0 +1,69 @@
+package httpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+func TestClientCredentialsAuthSt(t *testing.T) {
+	var counter int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientId, clientSecret, ok := r.BasicAuth()
+		if !ok || clientId != "cid" || clientSecret != "secret" ||
+			r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "a b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"t%d","token_type":"bearer","expires_in":60}`, atomic.AddInt32(&counter, 1))
+	}))
+	defer srv.Close()
+
+	ts := time.Now()
+
+	auth := NewClientCredentialsAuth(ClientCredentialsOptionsSt{
+		Client:        srv.Client(),
+		TokenUrl:      srv.URL,
+		ClientId:      "cid",
+		ClientSecret:  "secret",
+		Scopes:        []string{"a", "b"},
+		RefreshBefore: 10 * time.Second,
+	})
+	auth.now = func() time.Time { return ts }
+
+	applyToken := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, auth.Apply(context.Background(), req))
+		return req.Header.Get("Authorization")
+	}
+
+	require.Equal(t, "Bearer t1", applyToken())
+	require.Equal(t, "Bearer t1", applyToken())
+
+	// refresh before expiration
+	ts = ts.Add(55 * time.Second)
+	require.Equal(t, "Bearer t2", applyToken())
+
+	auth.Invalidate()
+	require.Equal(t, "Bearer t3", applyToken())
+
+	// bad credentials
+	auth = NewClientCredentialsAuth(ClientCredentialsOptionsSt{
+		Client:   srv.Client(),
+		TokenUrl: srv.URL,
+		ClientId: "bad",
+	})
+	err := auth.Apply(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.IsType(t, dopErrs.ErrWithDesc{}, err)
+	require.Equal(t, ErrAuthTokenFetch, err.(dopErrs.ErrWithDesc).Err)
+}
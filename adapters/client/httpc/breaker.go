@@ -0,0 +1,161 @@
+package httpc
+
+import (
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+var defaultCircuitBreakerOptions = CircuitBreakerOptionsSt{
+	FailureThreshold:    5,
+	CoolDown:            30 * time.Second,
+	HalfOpenMaxRequests: 1,
+}
+
+type CircuitBreakerOptionsSt struct {
+	FailureThreshold    int                         // consecutive failures to open
+	CoolDown            time.Duration               // time in open state before probing
+	HalfOpenMaxRequests int                         // probe requests in half-open state, all must succeed to close
+	OnStateChange       func(from, to CircuitState) // called under lock, must not call the breaker
+}
+
+func (o *CircuitBreakerOptionsSt) mergeWithDefaults() {
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = defaultCircuitBreakerOptions.FailureThreshold
+	}
+	if o.CoolDown <= 0 {
+		o.CoolDown = defaultCircuitBreakerOptions.CoolDown
+	}
+	if o.HalfOpenMaxRequests <= 0 {
+		o.HalfOpenMaxRequests = defaultCircuitBreakerOptions.HalfOpenMaxRequests
+	}
+}
+
+type CircuitBreakerSt struct {
+	opts CircuitBreakerOptionsSt
+	now  func() time.Time
+
+	state     CircuitState
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+	mu        sync.Mutex
+}
+
+func NewCircuitBreaker(opts CircuitBreakerOptionsSt) *CircuitBreakerSt {
+	opts.mergeWithDefaults()
+
+	return &CircuitBreakerSt{
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+func (b *CircuitBreakerSt) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState()
+
+	return b.state
+}
+
+// Allow reports whether request can be sent. Every allowed request must be followed by Done
+func (b *CircuitBreakerSt) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshState()
+
+	switch b.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if b.probes >= b.opts.HalfOpenMaxRequests {
+			return false
+		}
+		b.probes++
+	}
+
+	return true
+}
+
+func (b *CircuitBreakerSt) Done(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		if success {
+			b.failures = 0
+		} else {
+			b.failures++
+			if b.failures >= b.opts.FailureThreshold {
+				b.setState(CircuitOpen)
+			}
+		}
+	case CircuitHalfOpen:
+		if success {
+			b.successes++
+			if b.successes >= b.opts.HalfOpenMaxRequests {
+				b.setState(CircuitClosed)
+			}
+		} else {
+			b.setState(CircuitOpen)
+		}
+	}
+}
+
+// Ignore releases request allowed by Allow without affecting state, e.g. when it was canceled by caller
+func (b *CircuitBreakerSt) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *CircuitBreakerSt) refreshState() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.opts.CoolDown {
+		b.setState(CircuitHalfOpen)
+	}
+}
+
+func (b *CircuitBreakerSt) setState(state CircuitState) {
+	from := b.state
+
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+
+	if state == CircuitOpen {
+		b.openedAt = b.now()
+	}
+
+	if b.opts.OnStateChange != nil && from != state {
+		b.opts.OnStateChange(from, state)
+	}
+}
@@ -0,0 +1,62 @@
+package httpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerSt(t *testing.T) {
+	ts := time.Now()
+
+	stateChanges := make([]CircuitState, 0)
+
+	b := NewCircuitBreaker(CircuitBreakerOptionsSt{
+		FailureThreshold:    3,
+		CoolDown:            time.Minute,
+		HalfOpenMaxRequests: 2,
+		OnStateChange: func(from, to CircuitState) {
+			stateChanges = append(stateChanges, to)
+		},
+	})
+	b.now = func() time.Time { return ts }
+
+	// success resets failures counter
+	for _, success := range []bool{false, false, true, false, false} {
+		require.True(t, b.Allow())
+		b.Done(success)
+	}
+	require.Equal(t, CircuitClosed, b.State())
+
+	require.True(t, b.Allow())
+	b.Done(false)
+	require.Equal(t, CircuitOpen, b.State())
+	require.False(t, b.Allow())
+
+	ts = ts.Add(time.Minute)
+	require.Equal(t, CircuitHalfOpen, b.State())
+
+	// failed probe opens circuit again
+	require.True(t, b.Allow())
+	b.Done(false)
+	require.Equal(t, CircuitOpen, b.State())
+
+	ts = ts.Add(time.Minute)
+
+	require.True(t, b.Allow())
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+	b.Done(true)
+	require.Equal(t, CircuitHalfOpen, b.State())
+	b.Done(true)
+	require.Equal(t, CircuitClosed, b.State())
+
+	require.Equal(t, []CircuitState{
+		CircuitOpen,
+		CircuitHalfOpen,
+		CircuitOpen,
+		CircuitHalfOpen,
+		CircuitClosed,
+	}, stateChanges)
+}
@@ -0,0 +1,160 @@
+package httpc
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supernova0730/dop/adapters/cache"
+)
+
+var defaultCacheOptions = CacheOptionsSt{
+	KeyPrefix: "httpc:",
+	StoreTtl:  24 * time.Hour,
+}
+
+// CacheOptionsSt configures caching of successful GET responses without body.
+// Vary header is not supported, use KeyFunc if responses depend on request headers.
+// Requests with own credentials (Authorization header, Auth or BasicAuthCreds in request options) are not cached
+// without KeyFunc, client-level credentials are same for all requests
+type CacheOptionsSt struct {
+	Store      cache.Cache
+	KeyPrefix  string                      // default "httpc:"
+	KeyFunc    func(opts OptionsSt) string // default - url and params, must distinguish credentials if it is needed
+	DefaultTtl time.Duration               // freshness if response has no max-age/Expires, 0 - revalidate every time
+	MaxTtl     time.Duration               // caps freshness from response headers, 0 - unlimited
+	StoreTtl   time.Duration               // how long stale responses with ETag/Last-Modified are kept for revalidation, default 24h
+	// IgnoreCacheControl ignores Cache-Control/Expires of response, DefaultTtl is used
+	IgnoreCacheControl bool
+	// PrivateStore allows caching of "Cache-Control: private" responses,
+	// set it only if Store is not shared with other clients/users
+	PrivateStore bool
+}
+
+func (o *CacheOptionsSt) mergeWithDefaults() {
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = defaultCacheOptions.KeyPrefix
+	}
+	if o.StoreTtl <= 0 {
+		o.StoreTtl = defaultCacheOptions.StoreTtl
+	}
+}
+
+// GetMerged returns options with defaults
+func (o CacheOptionsSt) GetMerged() CacheOptionsSt {
+	o.mergeWithDefaults()
+	return o
+}
+
+func (o *CacheOptionsSt) GetKey(opts OptionsSt) string {
+	if o.KeyFunc != nil {
+		return o.KeyPrefix + o.KeyFunc(opts)
+	}
+
+	key := opts.BaseUrl + opts.Path
+
+	if len(opts.BaseParams) > 0 || len(opts.Params) > 0 {
+		qPars := url.Values{}
+		for k, v := range opts.BaseParams {
+			qPars[k] = v
+		}
+		for k, v := range opts.Params {
+			qPars[k] = v
+		}
+		key += "?" + qPars.Encode()
+	}
+
+	return o.KeyPrefix + key
+}
+
+// Freshness returns how long response is fresh and whether it can be stored.
+// ttl > 0 overrides freshness from response headers
+func (o *CacheOptionsSt) Freshness(header http.Header, ttl time.Duration, now time.Time) (time.Duration, bool) {
+	freshness := o.DefaultTtl
+
+	if !o.IgnoreCacheControl {
+		directives := ParseCacheControl(header.Get("Cache-Control"))
+
+		if _, ok := directives["no-store"]; ok {
+			return 0, false
+		}
+
+		if _, ok := directives["private"]; ok && !o.PrivateStore {
+			return 0, false
+		}
+
+		if v, ok := directives["max-age"]; ok {
+			if seconds, err := strconv.ParseInt(v, 10, 64); err == nil && seconds >= 0 {
+				freshness = time.Duration(seconds) * time.Second
+			}
+		} else if expires := header.Get("Expires"); expires != "" {
+			freshness = 0
+			if t, err := http.ParseTime(expires); err == nil {
+				date := now
+				if d, err := http.ParseTime(header.Get("Date")); err == nil {
+					date = d
+				}
+				if t.After(date) {
+					freshness = t.Sub(date)
+				}
+			}
+		}
+
+		if _, ok := directives["no-cache"]; ok {
+			freshness = 0
+		}
+
+		if o.MaxTtl > 0 && freshness > o.MaxTtl {
+			freshness = o.MaxTtl
+		}
+	}
+
+	if ttl > 0 {
+		freshness = ttl
+	}
+
+	return freshness, freshness > 0 || header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// CacheEntrySt is a cached response
+type CacheEntrySt struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+}
+
+func (e *CacheEntrySt) IsFresh(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
+
+// StoreTtl returns expiration for cache store
+func (e *CacheEntrySt) StoreTtl(opts *CacheOptionsSt, now time.Time) time.Duration {
+	ttl := e.ExpiresAt.Sub(now)
+
+	if (e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != "") && opts.StoreTtl > ttl {
+		ttl = opts.StoreTtl
+	}
+
+	return ttl
+}
+
+// ParseCacheControl returns directives with lower-cased names, value is empty for directives without it
+func ParseCacheControl(v string) map[string]string {
+	result := map[string]string{}
+
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(part, "=")
+
+		result[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return result
+}
@@ -0,0 +1,62 @@
+package httpc
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheOptionsSt_Freshness(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	tests := []struct {
+		name      string
+		opts      CacheOptionsSt
+		header    http.Header
+		ttl       time.Duration
+		freshness time.Duration
+		storable  bool
+	}{
+		{"empty", CacheOptionsSt{}, header(), 0, 0, false},
+		{"default", CacheOptionsSt{DefaultTtl: time.Minute}, header(), 0, time.Minute, true},
+		{"max_age", CacheOptionsSt{DefaultTtl: time.Minute}, header("Cache-Control", "public, max-age=10"), 0, 10 * time.Second, true},
+		{"max_ttl", CacheOptionsSt{MaxTtl: time.Second}, header("Cache-Control", "max-age=10"), 0, time.Second, true},
+		{"no_store", CacheOptionsSt{DefaultTtl: time.Minute}, header("Cache-Control", "no-store", "ETag", `"1"`), time.Hour, 0, false},
+		{"private", CacheOptionsSt{DefaultTtl: time.Minute}, header("Cache-Control", "private, max-age=10", "ETag", `"1"`), 0, 0, false},
+		{"private_store", CacheOptionsSt{PrivateStore: true}, header("Cache-Control", "private, max-age=10"), 0, 10 * time.Second, true},
+		{"no_cache_etag", CacheOptionsSt{}, header("Cache-Control", "no-cache, max-age=10", "ETag", `"1"`), 0, 0, true},
+		{"expires", CacheOptionsSt{}, header("Date", "Sat, 01 Jan 2022 00:00:00 GMT", "Expires", "Sat, 01 Jan 2022 00:01:00 GMT"), 0, time.Minute, true},
+		{"expired", CacheOptionsSt{DefaultTtl: time.Minute}, header("Expires", "0"), 0, 0, false},
+		{"ttl", CacheOptionsSt{}, header("Cache-Control", "max-age=10"), time.Hour, time.Hour, true},
+		{"ignore", CacheOptionsSt{IgnoreCacheControl: true}, header("Cache-Control", "no-store"), 0, 0, false},
+		{"last_modified", CacheOptionsSt{}, header("Last-Modified", "Sat, 01 Jan 2022 00:00:00 GMT"), 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			freshness, storable := tt.opts.Freshness(tt.header, tt.ttl, now)
+			require.Equal(t, tt.freshness, freshness)
+			require.Equal(t, tt.storable, storable)
+		})
+	}
+}
+
+func TestCacheOptionsSt_GetKey(t *testing.T) {
+	opts := CacheOptionsSt{}.GetMerged()
+
+	require.Equal(t, "httpc:http://a/items?a=1&b=2", opts.GetKey(OptionsSt{
+		BaseUrl:    "http://a/",
+		BaseParams: map[string][]string{"b": {"2"}},
+		Path:       "items",
+		Params:     map[string][]string{"a": {"1"}},
+	}))
+}
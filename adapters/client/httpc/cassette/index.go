@@ -0,0 +1,459 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+const (
+	ErrNoMatch  = dopErrs.Err("cassette_no_match")
+	ErrNotFound = dopErrs.Err("cassette_not_found")
+)
+
+// DefaultRedactOptions is used if OptionsSt.Redact is nil, cassettes are usually committed,
+// so credentials are masked also in urls and bodies
+var DefaultRedactOptions = httpc.RedactOptionsSt{
+	Params:     []string{"access_token", "refresh_token", "id_token", "client_secret", "password", "token"},
+	JsonFields: []string{"access_token", "refresh_token", "id_token", "client_secret", "password", "token"},
+}
+
+type Mode int
+
+const (
+	ModeReplay Mode = iota // default, never send real requests, cassette file must exist
+	ModeRecord             // always send real requests and overwrite cassette on Stop
+	ModeAuto               // replay if cassette file exists, record otherwise, e.g. to record new cassettes locally
+)
+
+type OptionsSt struct {
+	Mode      Mode
+	Transport http.RoundTripper // used for recording, default - http.DefaultTransport
+	// Redact masks recorded headers, url params and form/json bodies, requests are matched in masked form.
+	// Default - DefaultRedactOptions (headers of httpc.DefaultRedactOptions are always masked)
+	Redact       *httpc.RedactOptionsSt
+	IgnoreMethod bool
+	IgnorePath   bool
+	IgnoreParams []string // params excluded from matching, "*" - all
+	MatchBody    bool     // json bodies are compared semantically
+	Repeat       bool     // replayed interactions may be served more than once
+	// Matcher replaces default matching if set, reqBody is masked
+	Matcher func(req *http.Request, reqBody []byte, rec *RequestSt) bool
+}
+
+type CassetteSt struct {
+	Interactions []*InteractionSt `json:"interactions"`
+}
+
+type InteractionSt struct {
+	Request  RequestSt  `json:"request"`
+	Response ResponseSt `json:"response"`
+}
+
+type RequestSt struct {
+	Method     string      `json:"method"`
+	Url        string      `json:"url"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"`
+}
+
+type ResponseSt struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"`
+}
+
+// St records or replays http interactions. Wrap returns httpc.HttpC wrapper for any client,
+// St itself is http.RoundTripper for clients built on net/http, see Client and Options
+type St struct {
+	path      string
+	opts      OptionsSt
+	recording bool
+
+	cassette *CassetteSt
+	used     []bool
+	mu       sync.Mutex
+}
+
+func New(path string, opts OptionsSt) (*St, error) {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.Redact == nil {
+		opts.Redact = &DefaultRedactOptions
+	}
+
+	c := &St{
+		path:     path,
+		opts:     opts,
+		cassette: &CassetteSt{Interactions: []*InteractionSt{}},
+	}
+
+	switch opts.Mode {
+	case ModeRecord:
+		c.recording = true
+	case ModeAuto:
+		err := c.load()
+		if err == ErrNotFound {
+			c.recording = true
+		} else if err != nil {
+			return nil, err
+		}
+	default:
+		err := c.load()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func Load(path string) (*CassetteSt, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	result := &CassetteSt{}
+
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *St) load() error {
+	cassette, err := Load(c.path)
+	if err != nil {
+		return err
+	}
+
+	c.cassette = cassette
+	c.used = make([]bool, len(cassette.Interactions))
+
+	return nil
+}
+
+func (c *St) IsRecording() bool {
+	return c.recording
+}
+
+// Client returns copy of base (may be nil) with the cassette as transport
+func (c *St) Client(base *http.Client) *http.Client {
+	result := &http.Client{}
+	if base != nil {
+		*result = *base
+	}
+
+	result.Transport = c
+
+	return result
+}
+
+// Options returns opts with cassette client, e.g. httpclient.New(lg, cas.Options(opts))
+func (c *St) Options(opts httpc.OptionsSt) httpc.OptionsSt {
+	opts.Client = c.Client(opts.Client)
+	return opts
+}
+
+func (c *St) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+
+	if req.Body != nil {
+		var err error
+
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.recording {
+		return c.record(req, reqBody)
+	}
+
+	return c.replay(req, reqBody)
+}
+
+func (c *St) record(req *http.Request, reqBody []byte) (*http.Response, error) {
+	outReq := req.Clone(req.Context())
+	outReq.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	outReq.ContentLength = int64(len(reqBody))
+
+	rep, err := c.opts.Transport.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+
+	repBody, err := ioutil.ReadAll(rep.Body)
+	rep.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	c.add(req, reqBody, rep.StatusCode, rep.Header, repBody)
+
+	rep.Body = ioutil.NopCloser(bytes.NewReader(repBody))
+	rep.ContentLength = int64(len(repBody))
+
+	return rep, nil
+}
+
+// add appends masked interaction
+func (c *St) add(req *http.Request, reqBody []byte, statusCode int, repHeader http.Header, repBody []byte) {
+	interaction := &InteractionSt{
+		Request: RequestSt{
+			Method:  req.Method,
+			Url:     c.redactUrl(req.URL).String(),
+			Headers: c.opts.Redact.RedactHeaders(req.Header),
+		},
+		Response: ResponseSt{
+			StatusCode: statusCode,
+			Headers:    c.opts.Redact.RedactHeaders(repHeader),
+		},
+	}
+	interaction.Request.Body, interaction.Request.BodyBase64 = encodeBody(
+		c.opts.Redact.RedactContent(req.Header.Get("Content-Type"), reqBody),
+	)
+	interaction.Response.Body, interaction.Response.BodyBase64 = encodeBody(
+		c.opts.Redact.RedactContent(repHeader.Get("Content-Type"), repBody),
+	)
+
+	c.mu.Lock()
+	c.cassette.Interactions = append(c.cassette.Interactions, interaction)
+	c.mu.Unlock()
+}
+
+func (c *St) replay(req *http.Request, reqBody []byte) (*http.Response, error) {
+	statusCode, header, repBody, err := c.find(req, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(repBody)),
+		ContentLength: int64(len(repBody)),
+		Request:       req,
+	}, nil
+}
+
+// find returns recorded response for request and marks the interaction as used
+func (c *St) find(req *http.Request, reqBody []byte) (int, http.Header, []byte, error) {
+	// recorded requests are masked
+	reqUrl := c.redactUrl(req.URL)
+	reqBody = c.opts.Redact.RedactContent(req.Header.Get("Content-Type"), reqBody)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matchIdx := -1
+
+	for i, interaction := range c.cassette.Interactions {
+		if c.used[i] && !c.opts.Repeat {
+			continue
+		}
+		if !c.matches(req, reqUrl, reqBody, &interaction.Request) {
+			continue
+		}
+		// prefer unused interactions, so sequences of same request are replayed in order
+		if !c.used[i] {
+			matchIdx = i
+			break
+		}
+		if matchIdx < 0 {
+			matchIdx = i
+		}
+	}
+
+	if matchIdx < 0 {
+		return 0, nil, nil, dopErrs.ErrWithDesc{
+			Err:  ErrNoMatch,
+			Desc: req.Method + " " + reqUrl.String(),
+		}
+	}
+
+	c.used[matchIdx] = true
+
+	recRep := c.cassette.Interactions[matchIdx].Response
+
+	repBody, err := decodeBody(recRep.Body, recRep.BodyBase64)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	header := http.Header{}
+	for k, v := range recRep.Headers {
+		header[k] = v
+	}
+
+	return recRep.StatusCode, header, repBody, nil
+}
+
+func (c *St) matches(req *http.Request, reqUrl *url.URL, reqBody []byte, rec *RequestSt) bool {
+	if c.opts.Matcher != nil {
+		return c.opts.Matcher(req, reqBody, rec)
+	}
+
+	if !c.opts.IgnoreMethod && req.Method != rec.Method {
+		return false
+	}
+
+	recUrl, err := url.Parse(rec.Url)
+	if err != nil {
+		return false
+	}
+
+	if !c.opts.IgnorePath && reqUrl.Path != recUrl.Path {
+		return false
+	}
+
+	if !paramsEqual(reqUrl.Query(), recUrl.Query(), c.opts.IgnoreParams) {
+		return false
+	}
+
+	if c.opts.MatchBody {
+		recBody, err := decodeBody(rec.Body, rec.BodyBase64)
+		if err != nil {
+			return false
+		}
+		if !bodiesEqual(reqBody, recBody) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *St) redactUrl(u *url.URL) *url.URL {
+	result := *u
+	result.RawQuery = c.opts.Redact.RedactParams(u.RawQuery)
+	result.User = nil
+
+	return &result
+}
+
+// Stop saves recorded interactions, does nothing in replay mode
+func (c *St) Stop() error {
+	if !c.recording {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, data, 0644)
+}
+
+// Unused returns interactions, which were not replayed
+func (c *St) Unused() []*InteractionSt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]*InteractionSt, 0)
+
+	if c.recording {
+		return result
+	}
+
+	for i, interaction := range c.cassette.Interactions {
+		if !c.used[i] {
+			result = append(result, interaction)
+		}
+	}
+
+	return result
+}
+
+func paramsEqual(a, b url.Values, ignore []string) bool {
+	for _, v := range ignore {
+		if v == "*" {
+			return true
+		}
+	}
+
+	filter := func(values url.Values) url.Values {
+		result := url.Values{}
+		for k, v := range values {
+			ignored := false
+			for _, x := range ignore {
+				if x == k {
+					ignored = true
+					break
+				}
+			}
+			if !ignored {
+				result[k] = v
+			}
+		}
+		return result
+	}
+
+	return reflect.DeepEqual(filter(a), filter(b))
+}
+
+func bodiesEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var aObj, bObj any
+
+	if json.Unmarshal(a, &aObj) != nil || json.Unmarshal(b, &bObj) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aObj, bObj)
+}
+
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeBody(body string, isBase64 bool) ([]byte, error) {
+	if isBase64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+
+	return []byte(body), nil
+}
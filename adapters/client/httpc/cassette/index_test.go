@@ -0,0 +1,169 @@
+package cassette
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/adapters/client/httpc/httpclient"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/adapters/sms/smss"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+func TestRecordReplay(t *testing.T) {
+	lg := zap.New("info", true)
+
+	path := filepath.Join(t.TempDir(), "sms", "send.json")
+
+	var counter int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&counter, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write(body)
+	}))
+
+	httpcOpts := httpc.OptionsSt{
+		BaseUrl:     srv.URL,
+		BaseHeaders: http.Header{"Authorization": {"Bearer secret"}},
+	}
+
+	// record
+	cas, err := New(path, OptionsSt{Mode: ModeAuto})
+	require.NoError(t, err)
+	require.True(t, cas.IsRecording())
+
+	require.True(t, smss.New(httpclient.New(lg, cas.Options(httpcOpts))).Send("+77001234567", "hello"))
+	require.NoError(t, cas.Stop())
+	require.EqualValues(t, 1, counter)
+
+	srv.Close()
+
+	recorded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, recorded.Interactions, 1)
+	require.Equal(t, "POST", recorded.Interactions[0].Request.Method)
+	require.Equal(t, "***", recorded.Interactions[0].Request.Headers.Get("Authorization"))
+	require.Equal(t, "***", recorded.Interactions[0].Response.Headers.Get("Set-Cookie"))
+
+	// replay without network
+	cas, err = New(path, OptionsSt{MatchBody: true})
+	require.NoError(t, err)
+	require.False(t, cas.IsRecording())
+
+	c := httpclient.New(lg, cas.Options(httpcOpts))
+
+	require.True(t, smss.New(c).Send("+77001234567", "hello"))
+	require.Empty(t, cas.Unused())
+
+	// interaction is used once
+	_, _, err = c.SendJson(map[string]any{"to": "+77001234567", "text": "hello", "sync": true}, httpc.OptionsSt{Method: "POST", Path: "send"})
+	require.Error(t, err)
+
+	// body mismatch
+	cas, err = New(path, OptionsSt{MatchBody: true, Repeat: true})
+	require.NoError(t, err)
+
+	c = httpclient.New(lg, cas.Options(httpcOpts))
+
+	require.False(t, smss.New(c).Send("+77001234567", "bye"))
+	require.True(t, smss.New(c).Send("+77001234567", "hello"))
+	require.True(t, smss.New(c).Send("+77001234567", "hello"))
+
+	// missing cassette, replay is default
+	_, err = New(filepath.Join(t.TempDir(), "none.json"), OptionsSt{})
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestReplayMatching(t *testing.T) {
+	cas := &St{
+		cassette: &CassetteSt{Interactions: []*InteractionSt{
+			{Request: RequestSt{Method: "GET", Url: "http://a/items?page=1&ts=1"}, Response: ResponseSt{StatusCode: 200, Body: "1"}},
+			{Request: RequestSt{Method: "GET", Url: "http://a/items?page=1&ts=1"}, Response: ResponseSt{StatusCode: 200, Body: "2"}},
+			{Request: RequestSt{Method: "POST", Url: "http://a/items", Body: `{"a":1,"b":2}`}, Response: ResponseSt{StatusCode: 201, Body: "3"}},
+		}},
+		used: make([]bool, 3),
+		opts: OptionsSt{IgnoreParams: []string{"ts"}, MatchBody: true},
+	}
+
+	send := func(method, url, body string) (string, error) {
+		req := httptest.NewRequest(method, url, nil)
+		rep, err := cas.replay(req, []byte(body))
+		if err != nil {
+			return "", err
+		}
+		repBody, _ := ioutil.ReadAll(rep.Body)
+		return string(repBody), nil
+	}
+
+	body, err := send("GET", "http://b/items?ts=2&page=1", "")
+	require.NoError(t, err)
+	require.Equal(t, "1", body)
+
+	body, err = send("GET", "http://b/items?page=1", "")
+	require.NoError(t, err)
+	require.Equal(t, "2", body)
+
+	_, err = send("GET", "http://b/items?page=2", "")
+	require.IsType(t, dopErrs.ErrWithDesc{}, err)
+	require.Equal(t, ErrNoMatch, err.(dopErrs.ErrWithDesc).Err)
+
+	body, err = send("POST", "http://b/items", `{"b":2, "a":1}`)
+	require.NoError(t, err)
+	require.Equal(t, "3", body)
+
+	data, err := json.Marshal(cas.Unused())
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(data))
+}
+
+func TestRecordRedact(t *testing.T) {
+	lg := zap.New("info", true)
+
+	path := filepath.Join(t.TempDir(), "token.json")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"s3cr3t-token","expires_in":60}`))
+	}))
+
+	opts := httpc.OptionsSt{BaseUrl: srv.URL}
+	reqOpts := httpc.OptionsSt{
+		Method: "POST",
+		Path:   "token",
+		Params: url.Values{"token": {"s3cr3t-param"}, "a": {"1"}},
+	}
+	form := url.Values{"client_id": {"cid"}, "client_secret": {"s3cr3t-creds"}}
+
+	cas, err := New(path, OptionsSt{Mode: ModeRecord, MatchBody: true})
+	require.NoError(t, err)
+
+	_, _, err = httpclient.New(lg, cas.Options(opts)).SendForm(form, reqOpts)
+	require.NoError(t, err)
+	require.NoError(t, cas.Stop())
+
+	srv.Close()
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "s3cr3t")
+
+	// matched in masked form
+	cas, err = New(path, OptionsSt{MatchBody: true})
+	require.NoError(t, err)
+	require.False(t, cas.IsRecording())
+
+	repBody, _, err := httpclient.New(lg, cas.Options(opts)).SendForm(form, reqOpts)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"access_token":"***","expires_in":60}`, string(repBody))
+}
@@ -0,0 +1,282 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+// HttpcSt is httpc.HttpC, which records requests of wrapped client or replays them without calling it,
+// e.g. jwts.New(cas.Wrap(httpClient)). Request url and headers are built from merged options of wrapped client,
+// so cassettes are interchangeable with the ones recorded by St as transport
+type HttpcSt struct {
+	cas   *St
+	httpc httpc.HttpC
+}
+
+// Wrap returns httpc.HttpC, in replay mode inner is used only for GetOptions
+func (c *St) Wrap(inner httpc.HttpC) *HttpcSt {
+	return &HttpcSt{
+		cas:   c,
+		httpc: inner,
+	}
+}
+
+func (c *HttpcSt) GetOptions() httpc.OptionsSt {
+	return c.httpc.GetOptions()
+}
+
+func (c *HttpcSt) Send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendCtx(context.Background(), reqBody, opts)
+}
+
+func (c *HttpcSt) SendCtx(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
+	rep, err := c.DoCtx(ctx, reqBody, opts)
+
+	return rep.Body, rep.StatusCode, httpc.UnwrapError(err)
+}
+
+func (c *HttpcSt) Do(reqBody []byte, opts httpc.OptionsSt) (*httpc.ResponseSt, error) {
+	return c.DoCtx(context.Background(), reqBody, opts)
+}
+
+func (c *HttpcSt) DoCtx(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) (*httpc.ResponseSt, error) {
+	req, err := c.newRequest(ctx, opts)
+	if err != nil {
+		return &httpc.ResponseSt{}, httpc.NewError(&httpc.ResponseSt{}, err)
+	}
+
+	if c.cas.recording {
+		rep, err := c.httpc.DoCtx(ctx, reqBody, opts)
+		if rep != nil && rep.StatusCode > 0 {
+			c.cas.add(req, reqBody, rep.StatusCode, rep.Header, rep.Body)
+		}
+		return rep, err
+	}
+
+	startTime := time.Now()
+
+	statusCode, header, repBody, err := c.cas.find(req, reqBody)
+	if err != nil {
+		return &httpc.ResponseSt{}, httpc.NewError(&httpc.ResponseSt{}, err)
+	}
+
+	rep := &httpc.ResponseSt{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       repBody,
+		Duration:   time.Since(startTime),
+		Attempts:   1,
+	}
+
+	if err = statusError(statusCode); err != nil {
+		return rep, httpc.NewError(rep, err)
+	}
+
+	return rep, nil
+}
+
+func (c *HttpcSt) SendJson(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendJsonCtx(context.Background(), reqObj, opts)
+}
+
+func (c *HttpcSt) SendJsonCtx(ctx context.Context, reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	reqBody, err := json.Marshal(reqObj)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts.Headers = headersWithValue(opts.Headers, "Content-Type", "application/json")
+
+	return c.SendCtx(ctx, reqBody, opts)
+}
+
+func (c *HttpcSt) SendForm(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendFormCtx(context.Background(), reqObj, opts)
+}
+
+func (c *HttpcSt) SendFormCtx(ctx context.Context, reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	opts.Headers = headersWithValue(opts.Headers, "Content-Type", "application/x-www-form-urlencoded")
+
+	return c.SendCtx(ctx, httpc.EncodeForm(reqObj), opts)
+}
+
+func (c *HttpcSt) SendMultipart(fields any, files []httpc.MultipartFileSt, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendMultipartCtx(context.Background(), fields, files, opts)
+}
+
+// SendMultipartCtx - boundary is random, so use OptionsSt.MatchBody only with custom Matcher
+func (c *HttpcSt) SendMultipartCtx(ctx context.Context, fields any, files []httpc.MultipartFileSt, opts httpc.OptionsSt) ([]byte, int, error) {
+	reqBody, contentType, err := httpc.EncodeMultipart(fields, files)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts.Headers = headersWithValue(opts.Headers, "Content-Type", contentType)
+
+	return c.SendCtx(ctx, reqBody, opts)
+}
+
+func (c *HttpcSt) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendRecvJsonCtx(context.Background(), reqBody, repObj, statusRepObj, opts)
+}
+
+func (c *HttpcSt) SendRecvJsonCtx(ctx context.Context, reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	opts.Headers = headersWithValue(opts.Headers, "Accept", "application/json")
+
+	repBody, statusCode, err := c.SendCtx(ctx, reqBody, opts)
+	if err != nil {
+		if err != dopErrs.BadStatusCode && err != dopErrs.NotAuthorized && err != dopErrs.PermissionDenied {
+			return repBody, statusCode, err
+		}
+	}
+
+	if len(repBody) > 0 {
+		if err == nil {
+			if repObj != nil {
+				err = json.Unmarshal(repBody, repObj)
+			}
+		} else if statusCode > 0 {
+			if statusRepObj != nil {
+				if rObj, ok := statusRepObj[statusCode]; ok {
+					err = json.Unmarshal(repBody, rObj)
+				}
+			}
+		}
+	}
+
+	return repBody, statusCode, err
+}
+
+func (c *HttpcSt) SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendJsonRecvJsonCtx(context.Background(), reqObj, repObj, statusRepObj, opts)
+}
+
+func (c *HttpcSt) SendJsonRecvJsonCtx(ctx context.Context, reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	reqBody, err := json.Marshal(reqObj)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts.Headers = headersWithValue(opts.Headers, "Content-Type", "application/json")
+
+	return c.SendRecvJsonCtx(ctx, reqBody, repObj, statusRepObj, opts)
+}
+
+func (c *HttpcSt) SendStream(reqBody io.Reader, opts httpc.OptionsSt) (*httpc.StreamRepSt, error) {
+	return c.SendStreamCtx(context.Background(), reqBody, opts)
+}
+
+// SendStreamCtx reads whole request and response bodies to record or match them
+func (c *HttpcSt) SendStreamCtx(ctx context.Context, reqBody io.Reader, opts httpc.OptionsSt) (*httpc.StreamRepSt, error) {
+	var raw []byte
+
+	if reqBody != nil {
+		var err error
+
+		raw, err = ioutil.ReadAll(reqBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := c.newRequest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cas.recording {
+		rep, err := c.httpc.SendStreamCtx(ctx, bytes.NewReader(raw), opts)
+		if rep == nil {
+			return nil, err
+		}
+
+		repBody, rErr := ioutil.ReadAll(rep.Body)
+		rep.Body.Close()
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		c.cas.add(req, raw, rep.StatusCode, rep.Header, repBody)
+
+		rep.Body = ioutil.NopCloser(bytes.NewReader(repBody))
+
+		return rep, err
+	}
+
+	statusCode, header, repBody, err := c.cas.find(req, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpc.StreamRepSt{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(repBody)),
+	}, statusError(statusCode)
+}
+
+// newRequest builds request like httpclient does, it is used only for recording and matching
+func (c *HttpcSt) newRequest(ctx context.Context, opts httpc.OptionsSt) (*http.Request, error) {
+	opts = c.httpc.GetOptions().GetMergedWith(opts)
+
+	req, err := http.NewRequestWithContext(ctx, opts.Method, opts.BaseUrl+opts.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range opts.BaseHeaders {
+		req.Header[k] = v
+	}
+	for k, v := range opts.Headers {
+		req.Header[k] = v
+	}
+
+	if len(opts.BaseParams) > 0 || len(opts.Params) > 0 {
+		qPars := url.Values{}
+		for k, v := range opts.BaseParams {
+			qPars[k] = v
+		}
+		for k, v := range opts.Params {
+			qPars[k] = v
+		}
+		req.URL.RawQuery = qPars.Encode()
+	}
+
+	return req, nil
+}
+
+func statusError(statusCode int) error {
+	if statusCode >= 200 && statusCode <= 299 {
+		return nil
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return dopErrs.NotAuthorized
+	case http.StatusForbidden:
+		return dopErrs.PermissionDenied
+	}
+
+	return dopErrs.BadStatusCode
+}
+
+// headersWithValue returns copy of headers, so caller's map is not modified
+func headersWithValue(headers http.Header, key, value string) http.Header {
+	result := make(http.Header, len(headers)+1)
+	for k, v := range headers {
+		result[k] = v
+	}
+
+	result.Set(key, value)
+
+	return result
+}
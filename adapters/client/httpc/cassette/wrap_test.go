@@ -0,0 +1,84 @@
+package cassette
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/adapters/client/httpc/mock"
+	"github.com/supernova0730/dop/adapters/jwt/jwts"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+var _ httpc.HttpC = (*HttpcSt)(nil)
+
+func TestWrap(t *testing.T) {
+	lg := zap.New("info", true)
+
+	path := filepath.Join(t.TempDir(), "jwt.json")
+
+	newInner := func() *mock.St {
+		c := mock.New(lg)
+		c.SetOptions(httpc.OptionsSt{BaseUrl: "http://jwts/"})
+		return c
+	}
+
+	// tokens of test service are not secrets, default options would mask them
+	casOpts := OptionsSt{MatchBody: true, Redact: &httpc.RedactOptionsSt{}}
+
+	// record
+	inner := newInner()
+	inner.SetResponseSequence("POST", "jwt",
+		mock.ResponseSt{Obj: map[string]any{"token": "t0ken"}},
+		mock.ResponseSt{StatusCode: 401},
+	)
+
+	casOpts.Mode = ModeRecord
+
+	cas, err := New(path, casOpts)
+	require.NoError(t, err)
+
+	p := jwts.New(cas.Wrap(inner))
+
+	token, err := p.Create("42", 60, nil)
+	require.NoError(t, err)
+	require.Equal(t, "t0ken", token)
+
+	_, err = p.Create("43", 60, nil)
+	require.ErrorIs(t, err, dopErrs.NotAuthorized)
+
+	require.NoError(t, cas.Stop())
+	require.Len(t, inner.GetRequests(), 2)
+
+	recorded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, recorded.Interactions, 2)
+	require.Equal(t, "http://jwts/jwt", recorded.Interactions[0].Request.Url)
+	require.JSONEq(t, `{"sub":"42","exp_seconds":60}`, recorded.Interactions[0].Request.Body)
+
+	// replay, wrapped client is not called
+	inner = newInner()
+
+	casOpts.Mode = ModeReplay
+
+	cas, err = New(path, casOpts)
+	require.NoError(t, err)
+	require.False(t, cas.IsRecording())
+
+	p = jwts.New(cas.Wrap(inner))
+
+	_, err = p.Create("43", 60, nil)
+	require.ErrorIs(t, err, dopErrs.NotAuthorized)
+
+	token, err = p.Create("42", 60, nil)
+	require.NoError(t, err)
+	require.Equal(t, "t0ken", token)
+
+	_, err = p.Create("44", 60, nil)
+	require.ErrorIs(t, err, ErrNoMatch)
+
+	require.Empty(t, inner.GetRequests())
+	require.Empty(t, cas.Unused())
+}
@@ -1,5 +1,9 @@
 package httpc
 
+import (
+	"github.com/supernova0730/dop/dopErrs"
+)
+
 const (
 	LogRequest            = 1
 	LogResponse           = 2
@@ -8,3 +12,9 @@ const (
 	NoLogPermissionDenied = 16
 	NoLogBadStatus        = 32
 )
+
+const (
+	ErrResponseTooLarge = dopErrs.Err("response_too_large")
+	ErrAuthTokenFetch   = dopErrs.Err("auth_token_fetch")
+	ErrRateLimited      = dopErrs.Err("rate_limited")
+)
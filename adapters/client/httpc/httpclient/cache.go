@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/supernova0730/dop/adapters/client/httpc"
+)
+
+type cacheCtxKeyT struct{}
+
+// cacheStateSt is passed to cacheInterceptor through request context
+type cacheStateSt struct {
+	key         string
+	ttl         time.Duration
+	entry       *httpc.CacheEntrySt // stale entry for revalidation
+	revalidated bool
+}
+
+// isCacheable checks merged opts, reqCreds - request has own credentials
+func (c *St) isCacheable(reqBody []byte, opts httpc.OptionsSt, reqCreds bool) bool {
+	if c.cache == nil || len(reqBody) > 0 {
+		return false
+	}
+
+	// default key does not distinguish callers
+	if reqCreds && c.cache.KeyFunc == nil {
+		return false
+	}
+
+	if opts.Method != "" && opts.Method != http.MethodGet {
+		return false
+	}
+
+	_, noStore := httpc.ParseCacheControl(opts.Headers.Get("Cache-Control"))["no-store"]
+
+	return !noStore
+}
+
+// hasCredentials checks request (not merged) options
+func hasCredentials(opts httpc.OptionsSt) bool {
+	return opts.Auth != nil || opts.BasicAuthCreds != nil ||
+		opts.Headers.Get("Authorization") != "" || opts.BaseHeaders.Get("Authorization") != ""
+}
+
+// cacheLookup returns fresh entry or state for request, which revalidates stale entry and stores response
+func (c *St) cacheLookup(opts httpc.OptionsSt) (*httpc.CacheEntrySt, *cacheStateSt) {
+	state := &cacheStateSt{
+		key: c.cache.GetKey(opts),
+		ttl: opts.CacheTtl,
+	}
+
+	entry := &httpc.CacheEntrySt{}
+
+	ok, err := c.cache.Store.GetJsonObj(state.key, entry)
+	if err != nil {
+		c.lg.Errorw(opts.BaseLogPrefix+opts.LogPrefix+"Fail to get cached response", err, "key", state.key)
+		return nil, state
+	}
+	if !ok {
+		return nil, state
+	}
+
+	_, noCache := httpc.ParseCacheControl(opts.Headers.Get("Cache-Control"))["no-cache"]
+
+	if !noCache && entry.IsFresh(time.Now()) {
+		return entry, nil
+	}
+
+	state.entry = entry
+
+	return nil, state
+}
+
+func (c *St) cacheStore(key string, entry *httpc.CacheEntrySt, opts httpc.OptionsSt) {
+	ttl := entry.StoreTtl(c.cache, time.Now())
+	if ttl <= 0 {
+		return
+	}
+
+	err := c.cache.Store.SetJsonObj(key, entry, ttl)
+	if err != nil {
+		c.lg.Errorw(opts.BaseLogPrefix+opts.LogPrefix+"Fail to cache response", err, "key", key)
+	}
+}
+
+// cacheInterceptor is the innermost one, after logInterceptor, it makes requests conditional and
+// replaces "304 Not Modified" with cached response, so upper layers see it as a regular one
+func (c *St) cacheInterceptor(opts httpc.OptionsSt) httpc.Interceptor {
+	return func(next httpc.RoundTripFunc) httpc.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			state, _ := req.Context().Value(cacheCtxKeyT{}).(*cacheStateSt)
+			if state == nil {
+				return next(req)
+			}
+
+			if state.entry != nil {
+				if v := state.entry.Header.Get("ETag"); v != "" && req.Header.Get("If-None-Match") == "" {
+					req.Header.Set("If-None-Match", v)
+				}
+				if v := state.entry.Header.Get("Last-Modified"); v != "" && req.Header.Get("If-Modified-Since") == "" {
+					req.Header.Set("If-Modified-Since", v)
+				}
+			}
+
+			rep, err := next(req)
+			if err != nil {
+				return rep, err
+			}
+
+			now := time.Now()
+
+			if rep.StatusCode == http.StatusNotModified && state.entry != nil {
+				_ = rep.Body.Close()
+
+				entry := state.entry
+				if entry.Header == nil {
+					entry.Header = http.Header{}
+				}
+				for _, k := range []string{"Cache-Control", "Expires", "Date", "ETag", "Last-Modified"} {
+					if v := rep.Header.Values(k); len(v) > 0 {
+						entry.Header[k] = v
+					}
+				}
+
+				freshness, _ := c.cache.Freshness(entry.Header, state.ttl, now)
+				entry.ExpiresAt = now.Add(freshness)
+
+				c.cacheStore(state.key, entry, opts)
+
+				state.revalidated = true
+
+				return &http.Response{
+					Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+					StatusCode:    entry.StatusCode,
+					Proto:         rep.Proto,
+					ProtoMajor:    rep.ProtoMajor,
+					ProtoMinor:    rep.ProtoMinor,
+					Header:        entry.Header.Clone(),
+					Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+					ContentLength: int64(len(entry.Body)),
+					Request:       req,
+				}, nil
+			}
+
+			if rep.StatusCode != http.StatusOK {
+				return rep, nil
+			}
+
+			freshness, ok := c.cache.Freshness(rep.Header, state.ttl, now)
+			if !ok {
+				return rep, nil
+			}
+
+			repBody, err := readBody(rep.Body, opts.MaxResponseSize)
+			if err != nil {
+				// let the caller face the error
+				rep.Body = &multiReadCloserSt{
+					Reader: io.MultiReader(bytes.NewReader(repBody), rep.Body),
+					Closer: rep.Body,
+				}
+				return rep, nil
+			}
+			_ = rep.Body.Close()
+
+			rep.Body = ioutil.NopCloser(bytes.NewReader(repBody))
+
+			c.cacheStore(state.key, &httpc.CacheEntrySt{
+				StatusCode: rep.StatusCode,
+				Header:     rep.Header.Clone(),
+				Body:       repBody,
+				ExpiresAt:  now.Add(freshness),
+			}, opts)
+
+			return rep, nil
+		}
+	}
+}
+
+func withCacheState(ctx context.Context, state *cacheStateSt) context.Context {
+	return context.WithValue(ctx, cacheCtxKeyT{}, state)
+}
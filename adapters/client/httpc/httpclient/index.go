@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/supernova0730/dop/adapters/client/httpc"
@@ -18,6 +20,13 @@ import (
 type St struct {
 	lg   logger.Lite
 	opts httpc.OptionsSt
+
+	breaker *httpc.CircuitBreakerSt
+	limiter *httpc.RateLimiterSt
+	cache   *httpc.CacheOptionsSt
+
+	lastErr   error // last failure (transport error or 5xx), reset by success
+	lastErrMu sync.RWMutex
 }
 
 func New(lg logger.Lite, opts httpc.OptionsSt) *St {
@@ -25,67 +34,278 @@ func New(lg logger.Lite, opts httpc.OptionsSt) *St {
 		opts.BaseUrl = strings.TrimRight(opts.BaseUrl, "/") + "/"
 	}
 
-	return &St{
+	c := &St{
 		lg:   lg,
 		opts: opts,
 	}
+
+	if opts.CircuitBreaker != nil {
+		breakerOpts := *opts.CircuitBreaker
+		onStateChange := breakerOpts.OnStateChange
+		breakerOpts.OnStateChange = func(from, to httpc.CircuitState) {
+			c.lg.Warnw(opts.BaseLogPrefix+"Circuit breaker state changed",
+				"base_url", opts.BaseUrl,
+				"from", from.String(),
+				"to", to.String(),
+			)
+			if onStateChange != nil {
+				onStateChange(from, to)
+			}
+		}
+		c.breaker = httpc.NewCircuitBreaker(breakerOpts)
+	}
+
+	if opts.RateLimit != nil {
+		c.limiter = httpc.NewRateLimiter(*opts.RateLimit)
+	}
+
+	if opts.Cache != nil && opts.Cache.Store != nil {
+		cacheOpts := opts.Cache.GetMerged()
+		c.cache = &cacheOpts
+	}
+
+	return c
 }
 
 func (c *St) GetOptions() httpc.OptionsSt {
 	return c.opts
 }
 
+// CircuitBreakerState returns state of circuit breaker, always closed if it is not configured
+func (c *St) CircuitBreakerState() httpc.CircuitState {
+	if c.breaker == nil {
+		return httpc.CircuitClosed
+	}
+
+	return c.breaker.State()
+}
+
+// Check fails if circuit breaker is open or the last request failed with transport error or 5xx,
+// no request is sent. Implements health.Checker
+func (c *St) Check(ctx context.Context) error {
+	if c.CircuitBreakerState() == httpc.CircuitOpen {
+		return dopErrs.ServiceNA.WithDesc("circuit breaker is open")
+	}
+
+	c.lastErrMu.RLock()
+	defer c.lastErrMu.RUnlock()
+
+	if c.lastErr != nil {
+		return dopErrs.ServiceNA.WithDesc("last request failed: " + c.lastErr.Error())
+	}
+
+	return nil
+}
+
+// RateLimitStats returns throttled requests counters, zero if rate limit is not configured
+func (c *St) RateLimitStats() httpc.RateLimitStatsSt {
+	if c.limiter == nil {
+		return httpc.RateLimitStatsSt{}
+	}
+
+	return c.limiter.Stats()
+}
+
 func (c *St) Send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendCtx(context.Background(), reqBody, opts)
+}
+
+func (c *St) SendCtx(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
+	rep, err := c.DoCtx(ctx, reqBody, opts)
+
+	return rep.Body, rep.StatusCode, httpc.UnwrapError(err)
+}
+
+func (c *St) Do(reqBody []byte, opts httpc.OptionsSt) (*httpc.ResponseSt, error) {
+	return c.DoCtx(context.Background(), reqBody, opts)
+}
+
+// DoCtx always returns not nil response, the error is *httpc.ErrorSt
+func (c *St) DoCtx(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) (*httpc.ResponseSt, error) {
+	reqCreds := hasCredentials(opts)
+
 	opts = c.opts.GetMergedWith(opts)
 
 	origLogFlags := opts.LogFlags
+	retryPolicy := opts.GetRetryPolicy()
+	startTime := time.Now()
+
+	var cacheState *cacheStateSt
+
+	if c.isCacheable(reqBody, opts, reqCreds) {
+		var entry *httpc.CacheEntrySt
+
+		entry, cacheState = c.cacheLookup(opts)
+		if entry != nil {
+			return &httpc.ResponseSt{
+				StatusCode: entry.StatusCode,
+				Header:     entry.Header,
+				Body:       entry.Body,
+				Duration:   time.Since(startTime),
+				Cached:     true,
+			}, nil
+		}
+
+		ctx = withCacheState(ctx, cacheState)
+	}
 
 	var err error
-	var repBody []byte
-	var statusCode int
 
-	for i := opts.RetryCount; i >= 0; i-- {
-		if i == 0 {
+	rep := &httpc.ResponseSt{}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > opts.RetryCount {
 			opts.LogFlags = origLogFlags
 		} else {
 			opts.LogFlags = origLogFlags | httpc.NoLogError
 		}
 
-		repBody, statusCode, err = c.send(reqBody, opts)
-		if err != nil {
-			if opts.RetryInterval > 0 {
-				time.Sleep(opts.RetryInterval)
+		if c.limiter != nil {
+			if err = c.waitRateLimit(ctx, opts); err != nil {
+				rep = &httpc.ResponseSt{Attempts: rep.Attempts}
+				break
 			}
-			continue
 		}
 
-		return repBody, statusCode, nil
+		if c.breaker != nil && !c.breaker.Allow() {
+			rep = &httpc.ResponseSt{Attempts: rep.Attempts}
+			err = dopErrs.ServiceNA
+			break
+		}
+
+		rep.Attempts = attempt
+		rep.Body, rep.StatusCode, rep.Header, err = c.sendWithAuthRetry(ctx, reqBody, opts)
+
+		c.attemptDone(ctx, rep.StatusCode, err)
+
+		if err == nil || attempt > opts.RetryCount || ctx.Err() != nil {
+			break
+		}
+
+		delay, ok := retryPolicy.Delay(httpc.RetryStateSt{
+			Attempt:    attempt,
+			Elapsed:    time.Since(startTime),
+			Method:     opts.Method,
+			StatusCode: rep.StatusCode,
+			Header:     rep.Header,
+			Err:        err,
+		})
+		if !ok {
+			// error of this attempt was not logged
+			opts.LogFlags = origLogFlags
+			c.logRetryStop(opts, rep, err)
+			break
+		}
+
+		if delay > 0 && !httpc.SleepCtx(ctx, delay) {
+			err = ctx.Err()
+			break
+		}
 	}
 
-	return repBody, statusCode, err
+	rep.Duration = time.Since(startTime)
+	rep.Cached = cacheState != nil && cacheState.revalidated
+
+	if err != nil {
+		return rep, httpc.NewError(rep, err)
+	}
+
+	return rep, nil
 }
 
-func (c *St) send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
-	var err error
+func (c *St) waitRateLimit(ctx context.Context, opts httpc.OptionsSt) error {
+	err := c.limiter.Wait(ctx, opts.Path)
+	if err == httpc.ErrRateLimited && opts.LogFlags&httpc.NoLogError <= 0 {
+		c.lg.Warnw(opts.BaseLogPrefix+opts.LogPrefix+"Request is rate limited",
+			"method", opts.Method,
+			"uri", opts.BaseUrl+opts.Path,
+		)
+	}
 
-	uri := opts.BaseUrl + opts.Path
+	return err
+}
 
-	logError := opts.LogFlags&httpc.NoLogError <= 0
+// sendWithAuthRetry retries request once with fresh credentials on 401, if opts.Auth is httpc.AuthRefresher
+func (c *St) sendWithAuthRetry(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) ([]byte, int, http.Header, error) {
+	refresher, ok := opts.Auth.(httpc.AuthRefresher)
+	if !ok {
+		return c.send(ctx, reqBody, opts)
+	}
 
-	var req *http.Request
+	firstOpts := opts
+	firstOpts.LogFlags |= httpc.NoLogNotAuthorized
 
+	repBody, statusCode, repHeader, err := c.send(ctx, reqBody, firstOpts)
+	if err != dopErrs.NotAuthorized {
+		return repBody, statusCode, repHeader, err
+	}
+
+	refresher.Invalidate()
+
+	return c.send(ctx, reqBody, opts)
+}
+
+func (c *St) logRetryStop(opts httpc.OptionsSt, rep *httpc.ResponseSt, err error) {
+	if opts.LogFlags&httpc.NoLogError > 0 ||
+		(err == dopErrs.NotAuthorized && opts.LogFlags&httpc.NoLogNotAuthorized > 0) ||
+		(err == dopErrs.PermissionDenied && opts.LogFlags&httpc.NoLogPermissionDenied > 0) ||
+		(err == dopErrs.BadStatusCode && opts.LogFlags&httpc.NoLogBadStatus > 0) {
+		return
+	}
+
+	c.lg.Errorw(
+		opts.BaseLogPrefix+opts.LogPrefix+"Request failed, retry is not allowed", err,
+		"method", opts.Method,
+		"uri", opts.BaseUrl+opts.Path,
+		"status_code", rep.StatusCode,
+		"rep_body", opts.Redact.RedactBody(rep.Header.Get("Content-Type"), rep.Body),
+	)
+}
+
+func (c *St) send(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) ([]byte, int, http.Header, error) {
+	rep, cancel, err := c.doRequest(ctx, bytes.NewReader(reqBody), reqBody, false, opts)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer cancel()
+	defer rep.Body.Close()
+
+	statusCode := rep.StatusCode
+
+	// read response body
+	repBody, err := readBody(rep.Body, opts.MaxResponseSize)
+	if err != nil {
+		if opts.LogFlags&httpc.NoLogError <= 0 {
+			c.lg.Errorw(
+				opts.BaseLogPrefix+opts.LogPrefix+"Fail to read body", err,
+				"uri", opts.BaseUrl+opts.Path,
+				"params", opts.Redact.RedactParams(rep.Request.URL.RawQuery),
+				"req_body", opts.Redact.RedactBody(rep.Request.Header.Get("Content-Type"), reqBody),
+				"max_response_size", opts.MaxResponseSize,
+			)
+		}
+		return nil, statusCode, rep.Header, err
+	}
+
+	return repBody, statusCode, rep.Header, statusError(statusCode)
+}
+
+// doRequest sends request through interceptors, cancel must be called after response body is closed
+func (c *St) doRequest(ctx context.Context, reqBody io.Reader, logReqBody []byte, stream bool, opts httpc.OptionsSt) (*http.Response, context.CancelFunc, error) {
+	uri := opts.BaseUrl + opts.Path
+
+	cancel := context.CancelFunc(func() {})
 	if opts.Timeout > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
-		defer cancel()
-		req, err = http.NewRequestWithContext(ctx, opts.Method, uri, bytes.NewBuffer(reqBody))
-	} else {
-		req, err = http.NewRequest(opts.Method, uri, bytes.NewBuffer(reqBody))
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
 	}
+
+	req, err := http.NewRequestWithContext(ctx, opts.Method, uri, reqBody)
 	if err != nil {
-		if logError {
+		cancel()
+		if opts.LogFlags&httpc.NoLogError <= 0 {
 			c.lg.Errorw(opts.BaseLogPrefix+opts.LogPrefix+"Fail to create http-request", err)
 		}
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	// Headers
@@ -102,10 +322,6 @@ func (c *St) send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
 		}
 	}
 
-	// c.lg.Infow("dop request header", req.Header)
-
-	var queryParamsString string
-
 	// Query params
 	if len(opts.BaseParams) > 0 || len(opts.Params) > 0 {
 		qPars := url.Values{}
@@ -119,8 +335,7 @@ func (c *St) send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
 				qPars[k] = v
 			}
 		}
-		queryParamsString = qPars.Encode()
-		req.URL.RawQuery = queryParamsString
+		req.URL.RawQuery = qPars.Encode()
 	}
 
 	// Basic auth
@@ -128,98 +343,249 @@ func (c *St) send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
 		req.SetBasicAuth(opts.BasicAuthCreds.Username, opts.BasicAuthCreds.Password)
 	}
 
-	if opts.LogFlags&httpc.LogRequest > 0 {
-		c.lg.Infow(opts.BaseLogPrefix+opts.LogPrefix+"request: /"+opts.Path,
-			"uri", uri,
-			"params", queryParamsString,
-			"body", string(reqBody),
-		)
+	// Auth provider
+	if opts.Auth != nil {
+		err = opts.Auth.Apply(ctx, req)
+		if err != nil {
+			cancel()
+			if opts.LogFlags&httpc.NoLogError <= 0 {
+				c.lg.Errorw(opts.BaseLogPrefix+opts.LogPrefix+"Fail to apply auth", err, "uri", uri)
+			}
+			return nil, nil, err
+		}
+	}
+
+	// Interceptors
+	interceptors := make([]httpc.Interceptor, 0, len(opts.BaseInterceptors)+len(opts.Interceptors)+2)
+	interceptors = append(interceptors, opts.BaseInterceptors...)
+	interceptors = append(interceptors, opts.Interceptors...)
+	interceptors = append(interceptors, c.logInterceptor(opts, logReqBody, stream))
+	if c.cache != nil && !stream {
+		interceptors = append(interceptors, c.cacheInterceptor(opts))
 	}
 
 	// Do request
-	rep, err := opts.Client.Do(req)
+	rep, err := httpc.ChainInterceptors(opts.Client.Do, interceptors...)(req)
 	if err != nil {
-		if logError {
-			c.lg.Errorw(
-				opts.BaseLogPrefix+opts.LogPrefix+"Fail to send http-request", err,
-				"uri", uri,
-				"params", queryParamsString,
-				"req_body", string(reqBody),
-			)
+		cancel()
+		return nil, nil, redactUrlError(err, opts.Redact)
+	}
+
+	return rep, cancel, nil
+}
+
+func (c *St) SendStream(reqBody io.Reader, opts httpc.OptionsSt) (*httpc.StreamRepSt, error) {
+	return c.SendStreamCtx(context.Background(), reqBody, opts)
+}
+
+// SendStreamCtx sends request without retries and returns not read response body.
+// On success caller must close result Body. On bad status code the body is already read (limited by MaxResponseSize).
+func (c *St) SendStreamCtx(ctx context.Context, reqBody io.Reader, opts httpc.OptionsSt) (*httpc.StreamRepSt, error) {
+	opts = c.opts.GetMergedWith(opts)
+
+	if c.limiter != nil {
+		if err := c.waitRateLimit(ctx, opts); err != nil {
+			return nil, err
 		}
-		return nil, 0, err
 	}
-	defer rep.Body.Close()
 
-	statusCode := rep.StatusCode
+	if c.breaker != nil && !c.breaker.Allow() {
+		return nil, dopErrs.ServiceNA
+	}
+
+	rep, cancel, err := c.doRequest(ctx, reqBody, nil, true, opts)
+
+	if rep != nil {
+		c.attemptDone(ctx, rep.StatusCode, statusError(rep.StatusCode))
+	} else {
+		c.attemptDone(ctx, 0, err)
+	}
 
-	// read response body
-	repBody, err := ioutil.ReadAll(rep.Body)
 	if err != nil {
-		if logError {
-			c.lg.Errorw(
-				opts.BaseLogPrefix+opts.LogPrefix+"Fail to read body", err,
-				"uri", uri,
-				"params", queryParamsString,
-				"req_body", string(reqBody),
-			)
+		return nil, err
+	}
+
+	result := &httpc.StreamRepSt{
+		StatusCode: rep.StatusCode,
+		Header:     rep.Header,
+	}
+
+	if err = statusError(rep.StatusCode); err != nil {
+		defer cancel()
+		defer rep.Body.Close()
+
+		repBody, _ := readBody(rep.Body, opts.MaxResponseSize)
+		result.Body = ioutil.NopCloser(bytes.NewReader(repBody))
+
+		return result, err
+	}
+
+	result.Body = &cancelReadCloserSt{
+		ReadCloser: rep.Body,
+		cancel:     cancel,
+	}
+
+	return result, nil
+}
+
+// attemptDone reports result of attempt to breaker and health state,
+// canceled by caller attempts are ignored
+func (c *St) attemptDone(ctx context.Context, statusCode int, err error) {
+	if err != nil && ctx.Err() != nil {
+		if c.breaker != nil {
+			c.breaker.Ignore()
 		}
-		return nil, statusCode, err
+		return
 	}
 
-	if rep.StatusCode < 200 || rep.StatusCode > 299 {
-		if rep.StatusCode == 401 {
-			if logError && opts.LogFlags&httpc.NoLogNotAuthorized <= 0 {
-				c.lg.Errorw(
-					opts.BaseLogPrefix+opts.LogPrefix+"Bad status code", nil,
-					"status_code", rep.StatusCode,
-					"rep_body", string(repBody),
+	success := err == nil || (statusCode > 0 && statusCode < 500)
+
+	if c.breaker != nil {
+		c.breaker.Done(success)
+	}
+
+	c.lastErrMu.Lock()
+	defer c.lastErrMu.Unlock()
+
+	if success {
+		c.lastErr = nil
+	} else {
+		c.lastErr = err
+	}
+}
+
+// redactUrlError masks query params in url of transport error, its message is logged, stored for Check and returned
+func redactUrlError(err error, redact *httpc.RedactOptionsSt) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+
+	u, pErr := url.Parse(urlErr.URL)
+	if pErr != nil || u.RawQuery == "" {
+		return err
+	}
+
+	u.RawQuery = redact.RedactParams(u.RawQuery)
+
+	return &url.Error{Op: urlErr.Op, URL: u.String(), Err: urlErr.Err}
+}
+
+// logInterceptor goes after user interceptors and before cacheInterceptor, it logs request and response according to opts.LogFlags
+func (c *St) logInterceptor(opts httpc.OptionsSt, reqBody []byte, stream bool) httpc.Interceptor {
+	return func(next httpc.RoundTripFunc) httpc.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			uri := opts.BaseUrl + opts.Path
+			queryParamsString := opts.Redact.RedactParams(req.URL.RawQuery)
+			reqBodyLog := opts.Redact.RedactBody(req.Header.Get("Content-Type"), reqBody)
+
+			logError := opts.LogFlags&httpc.NoLogError <= 0
+
+			if opts.LogFlags&httpc.LogRequest > 0 {
+				c.lg.Infow(opts.BaseLogPrefix+opts.LogPrefix+"request: /"+opts.Path,
 					"uri", uri,
 					"params", queryParamsString,
-					"req_body", string(reqBody),
+					"headers", opts.Redact.RedactHeaders(req.Header),
+					"body", reqBodyLog,
 				)
 			}
-			return repBody, statusCode, dopErrs.NotAuthorized
-		}
-		if rep.StatusCode == 403 {
-			if logError && opts.LogFlags&httpc.NoLogPermissionDenied <= 0 {
+
+			rep, err := next(req)
+			if err != nil {
+				err = redactUrlError(err, opts.Redact)
+				if logError {
+					c.lg.Errorw(
+						opts.BaseLogPrefix+opts.LogPrefix+"Fail to send http-request", err,
+						"uri", uri,
+						"params", queryParamsString,
+						"req_body", reqBodyLog,
+					)
+				}
+				return nil, err
+			}
+
+			badStatus := rep.StatusCode < 200 || rep.StatusCode > 299
+
+			var logBadStatus bool
+
+			if badStatus && logError {
+				switch rep.StatusCode {
+				case 401:
+					logBadStatus = opts.LogFlags&httpc.NoLogNotAuthorized <= 0
+				case 403:
+					logBadStatus = opts.LogFlags&httpc.NoLogPermissionDenied <= 0
+				default:
+					logBadStatus = opts.LogFlags&httpc.NoLogBadStatus <= 0
+				}
+			}
+
+			logResponse := !badStatus && opts.LogFlags&httpc.LogResponse > 0
+
+			if logResponse && stream {
+				c.lg.Infow(opts.BaseLogPrefix+opts.LogPrefix+"response: /"+opts.Path,
+					"uri", uri,
+					"params", queryParamsString,
+					"status_code", rep.StatusCode,
+					"content_length", rep.ContentLength,
+				)
+				return rep, nil
+			}
+
+			if !logBadStatus && !logResponse {
+				return rep, nil
+			}
+
+			repBody, err := readBody(rep.Body, opts.MaxResponseSize)
+			if err == httpc.ErrResponseTooLarge {
+				// let the caller face the error
+				rep.Body = &multiReadCloserSt{
+					Reader: io.MultiReader(bytes.NewReader(repBody), rep.Body),
+					Closer: rep.Body,
+				}
+				return rep, nil
+			}
+			_ = rep.Body.Close()
+			if err != nil {
+				if logError {
+					c.lg.Errorw(
+						opts.BaseLogPrefix+opts.LogPrefix+"Fail to read body", err,
+						"uri", uri,
+						"params", queryParamsString,
+						"req_body", reqBodyLog,
+					)
+				}
+				return nil, err
+			}
+			rep.Body = ioutil.NopCloser(bytes.NewReader(repBody))
+
+			if logBadStatus {
 				c.lg.Errorw(
 					opts.BaseLogPrefix+opts.LogPrefix+"Bad status code", nil,
 					"status_code", rep.StatusCode,
-					"rep_body", string(repBody),
+					"rep_body", opts.Redact.RedactBody(rep.Header.Get("Content-Type"), repBody),
 					"uri", uri,
 					"params", queryParamsString,
-					"req_body", string(reqBody),
+					"req_body", reqBodyLog,
+				)
+			} else {
+				c.lg.Infow(opts.BaseLogPrefix+opts.LogPrefix+"response: /"+opts.Path,
+					"uri", uri,
+					"params", queryParamsString,
+					"req_body", reqBodyLog,
+					"body", opts.Redact.RedactBody(rep.Header.Get("Content-Type"), repBody),
 				)
 			}
-			return repBody, statusCode, dopErrs.PermissionDenied
-		}
-		if logError && opts.LogFlags&httpc.NoLogBadStatus <= 0 {
-			c.lg.Errorw(
-				opts.BaseLogPrefix+opts.LogPrefix+"Bad status code", nil,
-				"status_code", rep.StatusCode,
-				"rep_body", string(repBody),
-				"uri", uri,
-				"params", queryParamsString,
-				"req_body", string(reqBody),
-			)
-		}
-		return repBody, statusCode, dopErrs.BadStatusCode
-	}
 
-	if opts.LogFlags&httpc.LogResponse > 0 {
-		c.lg.Infow(opts.BaseLogPrefix+opts.LogPrefix+"response: /"+opts.Path,
-			"uri", uri,
-			"params", queryParamsString,
-			"req_body", string(reqBody),
-			"body", string(repBody),
-		)
+			return rep, nil
+		}
 	}
-
-	return repBody, statusCode, nil
 }
 
 func (c *St) SendJson(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendJsonCtx(context.Background(), reqObj, opts)
+}
+
+func (c *St) SendJsonCtx(ctx context.Context, reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
 	if opts.Headers == nil {
 		opts.Headers = http.Header{}
 	}
@@ -237,10 +603,61 @@ func (c *St) SendJson(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
 		return nil, 0, err
 	}
 
-	return c.Send(reqBody, opts)
+	return c.SendCtx(ctx, reqBody, opts)
+}
+
+func (c *St) SendForm(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendFormCtx(context.Background(), reqObj, opts)
+}
+
+// SendFormCtx sends url.Values or struct with "form" tags as application/x-www-form-urlencoded
+func (c *St) SendFormCtx(ctx context.Context, reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	opts.Headers = c.headersWithContentType(opts, "application/x-www-form-urlencoded")
+
+	return c.SendCtx(ctx, httpc.EncodeForm(reqObj), opts)
+}
+
+func (c *St) SendMultipart(fields any, files []httpc.MultipartFileSt, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendMultipartCtx(context.Background(), fields, files, opts)
+}
+
+// SendMultipartCtx sends fields (same as in SendFormCtx) and files as multipart/form-data
+func (c *St) SendMultipartCtx(ctx context.Context, fields any, files []httpc.MultipartFileSt, opts httpc.OptionsSt) ([]byte, int, error) {
+	reqBody, contentType, err := httpc.EncodeMultipart(fields, files)
+	if err != nil {
+		if opts.LogFlags&httpc.NoLogError <= 0 {
+			c.lg.Errorw(opts.LogPrefix+"Fail to encode multipart body", err)
+		}
+		return nil, 0, err
+	}
+
+	opts.Headers = c.headersWithContentType(opts, contentType)
+
+	return c.SendCtx(ctx, reqBody, opts)
+}
+
+// headersWithContentType returns copy of request headers (client ones if not set) with contentType,
+// which overrides Content-Type of base headers
+func (c *St) headersWithContentType(opts httpc.OptionsSt, contentType string) http.Header {
+	src := opts.Headers
+	if src == nil {
+		src = c.opts.Headers
+	}
+
+	result := make(http.Header, len(src)+1)
+	for k, v := range src {
+		result[k] = v
+	}
+	result["Content-Type"] = []string{contentType}
+
+	return result
 }
 
 func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendRecvJsonCtx(context.Background(), reqBody, repObj, statusRepObj, opts)
+}
+
+func (c *St) SendRecvJsonCtx(ctx context.Context, reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
 	if opts.Headers == nil {
 		opts.Headers = http.Header{}
 	}
@@ -250,13 +667,15 @@ func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any,
 		opts.Headers["Accept"] = []string{"application/json"}
 	}
 
-	repBody, statusCode, err := c.Send(reqBody, opts)
+	repBody, statusCode, err := c.SendCtx(ctx, reqBody, opts)
 	if err != nil {
 		if err != dopErrs.BadStatusCode && err != dopErrs.NotAuthorized && err != dopErrs.PermissionDenied {
 			return repBody, statusCode, err
 		}
 	}
 
+	redact := c.opts.GetMergedWith(opts).Redact
+
 	if len(repBody) > 0 {
 		if err == nil {
 			if repObj != nil {
@@ -265,9 +684,12 @@ func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any,
 					if opts.LogFlags&httpc.NoLogError <= 0 {
 						c.lg.Errorw(
 							opts.LogPrefix+"Fail to unmarshal body", err,
-							"opts", opts,
-							"req_body", string(reqBody),
-							"rep_body", string(repBody),
+							"method", opts.Method,
+							"path", opts.Path,
+							"params", redact.RedactValues(opts.Params).Encode(),
+							"headers", redact.RedactHeaders(opts.Headers),
+							"req_body", redact.RedactBody(opts.Headers.Get("Content-Type"), reqBody),
+							"rep_body", redact.RedactBody("application/json", repBody),
 						)
 					}
 				}
@@ -280,9 +702,12 @@ func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any,
 						if opts.LogFlags&httpc.NoLogError <= 0 {
 							c.lg.Errorw(
 								opts.LogPrefix+"Fail to unmarshal body", err,
-								"opts", opts,
-								"req_body", string(reqBody),
-								"rep_body", string(repBody),
+								"method", opts.Method,
+								"path", opts.Path,
+								"params", redact.RedactValues(opts.Params).Encode(),
+								"headers", redact.RedactHeaders(opts.Headers),
+								"req_body", redact.RedactBody(opts.Headers.Get("Content-Type"), reqBody),
+								"rep_body", redact.RedactBody("application/json", repBody),
 							)
 						}
 					}
@@ -295,6 +720,10 @@ func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any,
 }
 
 func (c *St) SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendJsonRecvJsonCtx(context.Background(), reqObj, repObj, statusRepObj, opts)
+}
+
+func (c *St) SendJsonRecvJsonCtx(ctx context.Context, reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
 	if opts.Headers == nil {
 		opts.Headers = http.Header{}
 	}
@@ -312,5 +741,53 @@ func (c *St) SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts
 		return nil, 0, err
 	}
 
-	return c.SendRecvJson(reqBody, repObj, statusRepObj, opts)
+	return c.SendRecvJsonCtx(ctx, reqBody, repObj, statusRepObj, opts)
+}
+
+func statusError(statusCode int) error {
+	if statusCode >= 200 && statusCode <= 299 {
+		return nil
+	}
+
+	switch statusCode {
+	case 401:
+		return dopErrs.NotAuthorized
+	case 403:
+		return dopErrs.PermissionDenied
+	default:
+		return dopErrs.BadStatusCode
+	}
+}
+
+// readBody reads whole body, returns httpc.ErrResponseTooLarge with read part if body is larger than maxSize
+func readBody(body io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return data, httpc.ErrResponseTooLarge
+	}
+
+	return data, nil
+}
+
+type cancelReadCloserSt struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (o *cancelReadCloserSt) Close() error {
+	defer o.cancel()
+	return o.ReadCloser.Close()
+}
+
+type multiReadCloserSt struct {
+	io.Reader
+	io.Closer
 }
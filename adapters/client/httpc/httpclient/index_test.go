@@ -0,0 +1,521 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/cache/mem"
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+var _ httpc.HttpC = (*St)(nil)
+
+func newTestServer(statusCodes ...int) (*httptest.Server, *int32) {
+	var counter int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&counter, 1)) - 1
+		if i >= len(statusCodes) {
+			i = len(statusCodes) - 1
+		}
+		w.WriteHeader(statusCodes[i])
+	}))
+
+	return srv, &counter
+}
+
+func TestSendRetry(t *testing.T) {
+	lg := zap.New("info", true)
+
+	srv, counter := newTestServer(503, 503, 200)
+	defer srv.Close()
+
+	c := New(lg, httpc.OptionsSt{Client: srv.Client(), BaseUrl: srv.URL})
+
+	_, statusCode, err := c.Send(nil, httpc.OptionsSt{
+		Method:     "GET",
+		RetryCount: 2,
+		RetryPolicy: httpc.RetryPolicySt{
+			InitialInterval: time.Millisecond,
+			Multiplier:      2,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	require.EqualValues(t, 3, atomic.LoadInt32(counter))
+
+	// client errors are not retried
+	srv, counter = newTestServer(400, 200)
+	defer srv.Close()
+
+	c = New(lg, httpc.OptionsSt{Client: srv.Client(), BaseUrl: srv.URL})
+
+	_, statusCode, err = c.Send(nil, httpc.OptionsSt{
+		Method:        "GET",
+		LogFlags:      httpc.NoLogBadStatus,
+		RetryCount:    2,
+		RetryInterval: time.Millisecond,
+	})
+	require.Equal(t, dopErrs.BadStatusCode, err)
+	require.Equal(t, 400, statusCode)
+	require.EqualValues(t, 1, atomic.LoadInt32(counter))
+}
+
+func TestSendCtxCancel(t *testing.T) {
+	srv, counter := newTestServer(503)
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{Client: srv.Client(), BaseUrl: srv.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+
+	_, _, err := c.SendCtx(ctx, nil, httpc.OptionsSt{
+		Method:        "GET",
+		LogFlags:      httpc.NoLogBadStatus,
+		RetryCount:    5,
+		RetryInterval: time.Minute,
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(startTime), time.Minute)
+	require.EqualValues(t, 1, atomic.LoadInt32(counter))
+}
+
+func TestSendCircuitBreaker(t *testing.T) {
+	srv, counter := newTestServer(500)
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		CircuitBreaker: &httpc.CircuitBreakerOptionsSt{
+			FailureThreshold: 2,
+			CoolDown:         time.Minute,
+		},
+	})
+
+	opts := httpc.OptionsSt{Method: "GET", LogFlags: httpc.NoLogBadStatus}
+
+	for i := 0; i < 2; i++ {
+		_, _, err := c.Send(nil, opts)
+		require.Equal(t, dopErrs.BadStatusCode, err)
+	}
+	require.Equal(t, httpc.CircuitOpen, c.CircuitBreakerState())
+
+	_, _, err := c.Send(nil, opts)
+	require.Equal(t, dopErrs.ServiceNA, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(counter))
+}
+
+func TestCheck(t *testing.T) {
+	srv, _ := newTestServer(500, 500, 200)
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		CircuitBreaker: &httpc.CircuitBreakerOptionsSt{
+			FailureThreshold: 2,
+			CoolDown:         50 * time.Millisecond,
+		},
+	})
+
+	opts := httpc.OptionsSt{Method: "GET", LogFlags: httpc.NoLogBadStatus}
+
+	require.NoError(t, c.Check(context.Background()))
+
+	_, _, _ = c.Send(nil, opts)
+	require.ErrorIs(t, c.Check(context.Background()), dopErrs.ServiceNA)
+
+	_, _, _ = c.Send(nil, opts)
+	err := c.Check(context.Background())
+	require.ErrorIs(t, err, dopErrs.ServiceNA)
+	require.Contains(t, err.Error(), "circuit breaker is open")
+
+	time.Sleep(60 * time.Millisecond)
+
+	_, _, err = c.Send(nil, opts)
+	require.NoError(t, err)
+	require.NoError(t, c.Check(context.Background()))
+}
+
+type testLoggerSt struct {
+	records []string
+}
+
+func (l *testLoggerSt) Infow(msg string, args ...any) {
+	l.records = append(l.records, fmt.Sprint(msg, args))
+}
+
+func (l *testLoggerSt) Warnw(msg string, args ...any) {
+	l.records = append(l.records, fmt.Sprint(msg, args))
+}
+
+func (l *testLoggerSt) Errorw(msg string, err any, args ...any) {
+	l.records = append(l.records, fmt.Sprint(msg, err, args))
+}
+
+func TestSendRedactTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // connection refused
+
+	lg := &testLoggerSt{}
+
+	c := New(lg, httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		Redact:  &httpc.RedactOptionsSt{Params: []string{"token"}},
+	})
+
+	_, _, err := c.Send(nil, httpc.OptionsSt{
+		Method: "GET",
+		Path:   "x",
+		Params: url.Values{"token": {"s3cr3t"}, "page": {"2"}},
+	})
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "s3cr3t")
+	require.Contains(t, err.Error(), "page=2")
+
+	var urlErr *url.Error
+	require.ErrorAs(t, err, &urlErr)
+
+	require.NotEmpty(t, lg.records)
+	for _, rec := range lg.records {
+		require.NotContains(t, rec, "s3cr3t")
+	}
+
+	err = c.Check(context.Background())
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "s3cr3t")
+}
+
+func TestSendInterceptors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Request-ID") + "," + r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	calls := make([]string, 0)
+
+	traceInterceptor := func(name string) httpc.Interceptor {
+		return func(next httpc.RoundTripFunc) httpc.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next(req)
+			}
+		}
+	}
+
+	c := New(zap.New("info", true), httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		BaseInterceptors: []httpc.Interceptor{
+			traceInterceptor("base"),
+			httpc.HeaderInterceptor("Authorization", func(req *http.Request) string { return "Bearer token" }),
+		},
+	})
+
+	repBody, _, err := c.Send(nil, httpc.OptionsSt{
+		Method: "GET",
+		Interceptors: []httpc.Interceptor{
+			traceInterceptor("request"),
+			httpc.HeaderInterceptor("X-Request-ID", func(req *http.Request) string { return "rid" }),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "rid,Bearer token", string(repBody))
+	require.Equal(t, []string{"base", "request"}, calls)
+}
+
+func TestSendStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Test", "1")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{Client: srv.Client(), BaseUrl: srv.URL})
+
+	reqBody := bytes.Repeat([]byte("0123456789"), 1000)
+
+	rep, err := c.SendStreamCtx(context.Background(), bytes.NewReader(reqBody), httpc.OptionsSt{
+		Method:          "POST",
+		Timeout:         time.Minute,
+		MaxResponseSize: 10, // not applied to streams
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, rep.StatusCode)
+	require.Equal(t, "1", rep.Header.Get("X-Test"))
+
+	repBody, err := ioutil.ReadAll(rep.Body)
+	require.NoError(t, err)
+	require.NoError(t, rep.Body.Close())
+	require.Equal(t, reqBody, repBody)
+
+	// buffered
+	_, _, err = c.Send(reqBody, httpc.OptionsSt{
+		Method:          "POST",
+		LogFlags:        httpc.NoLogError,
+		MaxResponseSize: 10,
+	})
+	require.Equal(t, httpc.ErrResponseTooLarge, err)
+
+	repBody, _, err = c.Send(reqBody, httpc.OptionsSt{
+		Method:          "POST",
+		MaxResponseSize: int64(len(reqBody)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, reqBody, repBody)
+}
+
+func TestSendForm(t *testing.T) {
+	var contentTypes []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		_, _ = w.Write([]byte(r.FormValue("a") + "," + r.Header.Get("X-Test")))
+	}))
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{
+		Client:      srv.Client(),
+		BaseUrl:     srv.URL,
+		BaseHeaders: http.Header{"Content-Type": {"application/json"}},
+		Headers:     http.Header{"X-Test": {"1"}},
+	})
+
+	repBody, _, err := c.SendForm(url.Values{"a": {"1"}}, httpc.OptionsSt{Method: "POST"})
+	require.NoError(t, err)
+	require.Equal(t, "1,1", string(repBody))
+
+	headers := http.Header{"X-Test": {"2"}}
+	repBody, _, err = c.SendMultipart(url.Values{"a": {"2"}}, nil, httpc.OptionsSt{Method: "POST", Headers: headers})
+	require.NoError(t, err)
+	require.Equal(t, "2,2", string(repBody))
+	require.Equal(t, http.Header{"X-Test": {"2"}}, headers)
+
+	require.Equal(t, "application/x-www-form-urlencoded", contentTypes[0])
+	require.Contains(t, contentTypes[1], "multipart/form-data")
+}
+
+func TestDo(t *testing.T) {
+	var counter int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", "v1")
+		if atomic.AddInt32(&counter, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{Client: srv.Client(), BaseUrl: srv.URL})
+
+	rep, err := c.Do(nil, httpc.OptionsSt{
+		Method:        "GET",
+		Path:          "obj",
+		RetryCount:    1,
+		RetryInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, rep.StatusCode)
+	require.Equal(t, "v1", rep.Header.Get("ETag"))
+	require.Equal(t, "ok", string(rep.Body))
+	require.Equal(t, 2, rep.Attempts)
+	require.Greater(t, rep.Duration, time.Duration(0))
+
+	rep, err = c.Do(nil, httpc.OptionsSt{
+		Method:   "GET",
+		Path:     "missing",
+		LogFlags: httpc.NoLogBadStatus,
+	})
+	require.ErrorIs(t, err, dopErrs.BadStatusCode)
+
+	var httpErr *httpc.ErrorSt
+	require.True(t, errors.As(err, &httpErr))
+	require.Equal(t, 404, httpErr.StatusCode)
+	require.Equal(t, "not found", string(httpErr.Body))
+	require.Equal(t, "v1", httpErr.Header.Get("ETag"))
+	require.Equal(t, 1, httpErr.Attempts)
+	require.Equal(t, 404, rep.StatusCode)
+}
+
+type testAuthSt struct {
+	tokens []string
+}
+
+func (o *testAuthSt) Apply(ctx context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+o.tokens[0])
+	return nil
+}
+
+func (o *testAuthSt) Invalidate() {
+	if len(o.tokens) > 1 {
+		o.tokens = o.tokens[1:]
+	}
+}
+
+func TestSendAuthRetry(t *testing.T) {
+	var counter int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&counter, 1)
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(zap.New("info", true), httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		Auth:    &testAuthSt{tokens: []string{"expired", "fresh"}},
+	})
+
+	_, statusCode, err := c.Send(nil, httpc.OptionsSt{Method: "GET"})
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	require.EqualValues(t, 2, atomic.LoadInt32(&counter))
+
+	// retried only once
+	_, statusCode, err = c.Send(nil, httpc.OptionsSt{
+		Method:   "GET",
+		LogFlags: httpc.NoLogNotAuthorized,
+		Auth:     &testAuthSt{tokens: []string{"expired", "wrong"}},
+	})
+	require.Equal(t, dopErrs.NotAuthorized, err)
+	require.Equal(t, 401, statusCode)
+	require.EqualValues(t, 4, atomic.LoadInt32(&counter))
+
+	// static credentials are not retried
+	_, statusCode, err = c.Send(nil, httpc.OptionsSt{
+		Method:   "POST",
+		LogFlags: httpc.NoLogNotAuthorized,
+		Auth:     &httpc.BearerAuthSt{Token: "wrong"},
+	})
+	require.Equal(t, dopErrs.NotAuthorized, err)
+	require.Equal(t, 401, statusCode)
+	require.EqualValues(t, 5, atomic.LoadInt32(&counter))
+}
+
+func TestSendRateLimit(t *testing.T) {
+	lg := zap.New("info", true)
+
+	srv, counter := newTestServer(200)
+	defer srv.Close()
+
+	c := New(lg, httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		RateLimit: &httpc.RateLimitOptionsSt{
+			Paths:    map[string]httpc.RateLimitSt{"send": {Rate: 1, Per: time.Hour}},
+			FailFast: true,
+		},
+	})
+
+	_, err := c.Do(nil, httpc.OptionsSt{Method: "POST", Path: "send"})
+	require.NoError(t, err)
+
+	_, err = c.Do(nil, httpc.OptionsSt{Method: "POST", Path: "send", LogFlags: httpc.NoLogError})
+	require.ErrorIs(t, err, httpc.ErrRateLimited)
+
+	_, err = c.Do(nil, httpc.OptionsSt{Method: "GET", Path: "status"})
+	require.NoError(t, err)
+
+	require.EqualValues(t, 2, atomic.LoadInt32(counter))
+	require.Equal(t, httpc.RateLimitStatsSt{Rejected: 1}, c.RateLimitStats())
+}
+
+func TestDoCache(t *testing.T) {
+	lg := zap.New("info", true)
+
+	var counter, notModifiedCounter int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&counter, 1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModifiedCounter, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	c := New(lg, httpc.OptionsSt{
+		Client:  srv.Client(),
+		BaseUrl: srv.URL,
+		Cache:   &httpc.CacheOptionsSt{Store: mem.New()},
+	})
+
+	do := func(opts httpc.OptionsSt) *httpc.ResponseSt {
+		rep, err := c.Do(nil, opts)
+		require.NoError(t, err)
+		return rep
+	}
+
+	rep := do(httpc.OptionsSt{Method: "GET", Path: "fresh"})
+	require.False(t, rep.Cached)
+	rep = do(httpc.OptionsSt{Method: "GET", Path: "fresh"})
+	require.True(t, rep.Cached)
+	require.Zero(t, rep.Attempts)
+	require.Equal(t, "/fresh", string(rep.Body))
+	require.EqualValues(t, 1, atomic.LoadInt32(&counter))
+
+	// other params, request no-store and not GET are not served from cache
+	do(httpc.OptionsSt{Method: "GET", Path: "fresh", Params: url.Values{"a": {"1"}}})
+	do(httpc.OptionsSt{Method: "GET", Path: "fresh", Headers: http.Header{"Cache-Control": {"no-store"}}})
+	do(httpc.OptionsSt{Method: "POST", Path: "fresh"})
+	require.EqualValues(t, 4, atomic.LoadInt32(&counter))
+
+	// requests with own credentials are not cached
+	rep = do(httpc.OptionsSt{Method: "GET", Path: "fresh", Headers: http.Header{"Authorization": {"Bearer a"}}})
+	require.False(t, rep.Cached)
+	rep = do(httpc.OptionsSt{Method: "GET", Path: "fresh", Auth: &httpc.BearerAuthSt{Token: "b"}})
+	require.False(t, rep.Cached)
+	require.EqualValues(t, 6, atomic.LoadInt32(&counter))
+
+	// revalidation
+	rep = do(httpc.OptionsSt{Method: "GET", Path: "etag"})
+	require.False(t, rep.Cached)
+	rep = do(httpc.OptionsSt{Method: "GET", Path: "etag"})
+	require.True(t, rep.Cached)
+	require.Equal(t, 200, rep.StatusCode)
+	require.Equal(t, "/etag", string(rep.Body))
+	require.EqualValues(t, 1, atomic.LoadInt32(&notModifiedCounter))
+
+	// ttl override
+	do(httpc.OptionsSt{Method: "GET", Path: "plain", CacheTtl: time.Minute})
+	rep = do(httpc.OptionsSt{Method: "GET", Path: "plain"})
+	require.True(t, rep.Cached)
+	require.EqualValues(t, 9, atomic.LoadInt32(&counter))
+}
@@ -0,0 +1,32 @@
+package httpc
+
+import (
+	"net/http"
+)
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+type Interceptor func(next RoundTripFunc) RoundTripFunc
+
+// ChainInterceptors wraps rt with interceptors, the first interceptor is the outermost
+func ChainInterceptors(rt RoundTripFunc, interceptors ...Interceptor) RoundTripFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		if interceptors[i] != nil {
+			rt = interceptors[i](rt)
+		}
+	}
+
+	return rt
+}
+
+// HeaderInterceptor sets header with value returned by valueFn, empty value is not set
+func HeaderInterceptor(key string, valueFn func(req *http.Request) string) Interceptor {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if v := valueFn(req); v != "" {
+				req.Header.Set(key, v)
+			}
+			return next(req)
+		}
+	}
+}
@@ -1,9 +1,44 @@
 package httpc
 
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
 type HttpC interface {
 	GetOptions() OptionsSt
 	Send(reqBody []byte, opts OptionsSt) ([]byte, int, error)
+	SendCtx(ctx context.Context, reqBody []byte, opts OptionsSt) ([]byte, int, error)
 	SendJson(reqObj any, opts OptionsSt) ([]byte, int, error)
+	SendJsonCtx(ctx context.Context, reqObj any, opts OptionsSt) ([]byte, int, error)
 	SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any, opts OptionsSt) ([]byte, int, error)
+	SendRecvJsonCtx(ctx context.Context, reqBody []byte, repObj any, statusRepObj map[int]any, opts OptionsSt) ([]byte, int, error)
 	SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts OptionsSt) ([]byte, int, error)
+	SendJsonRecvJsonCtx(ctx context.Context, reqObj, repObj any, statusRepObj map[int]any, opts OptionsSt) ([]byte, int, error)
+	SendForm(reqObj any, opts OptionsSt) ([]byte, int, error)
+	SendFormCtx(ctx context.Context, reqObj any, opts OptionsSt) ([]byte, int, error)
+	SendMultipart(fields any, files []MultipartFileSt, opts OptionsSt) ([]byte, int, error)
+	SendMultipartCtx(ctx context.Context, fields any, files []MultipartFileSt, opts OptionsSt) ([]byte, int, error)
+	Do(reqBody []byte, opts OptionsSt) (*ResponseSt, error)
+	DoCtx(ctx context.Context, reqBody []byte, opts OptionsSt) (*ResponseSt, error)
+	SendStream(reqBody io.Reader, opts OptionsSt) (*StreamRepSt, error)
+	SendStreamCtx(ctx context.Context, reqBody io.Reader, opts OptionsSt) (*StreamRepSt, error)
+}
+
+type AuthProvider interface {
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// AuthRefresher is optionally implemented by AuthProvider with refreshable credentials,
+// on 401 response the request is retried once after Invalidate
+type AuthRefresher interface {
+	// Invalidate drops cached credentials
+	Invalidate()
+}
+
+type RetryPolicy interface {
+	// Delay returns pause before the next attempt, false - if request must not be retried
+	Delay(state RetryStateSt) (time.Duration, bool)
 }
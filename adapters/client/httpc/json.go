@@ -0,0 +1,114 @@
+package httpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/supernova0730/dop/dopTools"
+)
+
+type JSONErrorSt[E any] struct {
+	*ErrorSt
+	ErrRep  E
+	Decoded bool // false - body is empty or not decodable into ErrRep
+}
+
+// DoJSON sends req as json (no body if req is nil) and decodes response body into Rep
+func DoJSON[Req, Rep any](ctx context.Context, c HttpC, opts OptionsSt, req Req) (Rep, error) {
+	var repObj Rep
+
+	rep, err := doJSON(ctx, c, opts, req)
+	if err != nil {
+		return repObj, err
+	}
+
+	if len(rep.Body) > 0 {
+		err = json.Unmarshal(rep.Body, &repObj)
+		if err != nil {
+			return repObj, NewError(rep, err)
+		}
+	}
+
+	return repObj, nil
+}
+
+// DoJSONWithErr is same as DoJSON, but on bad status code, which is in errStatuses (any if empty),
+// decodes response body into ErrRep and returns *JSONErrorSt[ErrRep]
+func DoJSONWithErr[Req, Rep, ErrRep any](ctx context.Context, c HttpC, opts OptionsSt, req Req, errStatuses ...int) (Rep, error) {
+	repObj, err := DoJSON[Req, Rep](ctx, c, opts, req)
+	if err == nil {
+		return repObj, nil
+	}
+
+	httpErr, ok := err.(*ErrorSt)
+	if !ok || httpErr.StatusCode == 0 || (httpErr.StatusCode >= 200 && httpErr.StatusCode <= 299) {
+		return repObj, err
+	}
+
+	if len(errStatuses) > 0 && !dopTools.SliceHasValue(errStatuses, httpErr.StatusCode) {
+		return repObj, err
+	}
+
+	result := &JSONErrorSt[ErrRep]{ErrorSt: httpErr}
+
+	if len(httpErr.Body) > 0 {
+		result.Decoded = json.Unmarshal(httpErr.Body, &result.ErrRep) == nil
+	}
+
+	return repObj, result
+}
+
+func doJSON(ctx context.Context, c HttpC, opts OptionsSt, req any) (*ResponseSt, error) {
+	var reqBody []byte
+
+	headers := http.Header{}
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+
+	baseOpts := c.GetOptions()
+
+	if !isNil(req) {
+		var err error
+
+		reqBody, err = json.Marshal(req)
+		if err != nil {
+			return &ResponseSt{}, NewError(&ResponseSt{}, err)
+		}
+
+		if !hasHeader("Content-Type", baseOpts, opts) {
+			headers.Set("Content-Type", "application/json")
+		}
+	}
+
+	if !hasHeader("Accept", baseOpts, opts) {
+		headers.Set("Accept", "application/json")
+	}
+
+	opts.Headers = headers
+
+	return c.DoCtx(ctx, reqBody, opts)
+}
+
+// isNil is true also for typed nil, e.g. (*reqSt)(nil) passed as Req
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+func hasHeader(key string, baseOpts, opts OptionsSt) bool {
+	return len(baseOpts.BaseHeaders.Values(key)) > 0 || len(baseOpts.Headers.Values(key)) > 0 ||
+		len(opts.BaseHeaders.Values(key)) > 0 || len(opts.Headers.Values(key)) > 0
+}
@@ -0,0 +1,75 @@
+package httpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/adapters/client/httpc/mock"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+type reqSt struct {
+	Name string `json:"name"`
+}
+
+type repSt struct {
+	Id int64 `json:"id"`
+}
+
+type errRepSt struct {
+	ErrorCode string `json:"error_code"`
+}
+
+func TestDoJSON(t *testing.T) {
+	c := mock.New(zap.New("info", true))
+
+	c.SetMethodResponse("POST", "obj", mock.ResponseSt{Obj: repSt{Id: 3}})
+	c.SetMethodResponse("PUT", "obj", mock.ResponseSt{StatusCode: 400, Obj: errRepSt{ErrorCode: "bad_name"}})
+	c.SetMethodResponse("DELETE", "obj", mock.ResponseSt{StatusCode: 500, Raw: []byte("oops")})
+
+	ctx := context.Background()
+
+	repObj, err := httpc.DoJSON[reqSt, repSt](ctx, c, httpc.OptionsSt{Method: "POST", Path: "obj"}, reqSt{Name: "n"})
+	require.NoError(t, err)
+	require.Equal(t, repSt{Id: 3}, repObj)
+
+	req, ok := c.GetRequest("obj", &reqSt{})
+	require.True(t, ok)
+	require.Equal(t, "application/json", req.Opts.Headers.Get("Content-Type"))
+	require.Equal(t, `{"name":"n"}`, string(req.Raw))
+
+	// typed nil, no body
+	c.SetMethodResponse("GET", "obj", mock.ResponseSt{Obj: repSt{Id: 4}})
+
+	repObj, err = httpc.DoJSON[*reqSt, repSt](ctx, c, httpc.OptionsSt{Method: "GET", Path: "obj"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, repSt{Id: 4}, repObj)
+
+	requests := c.GetRequests()
+	req = requests[len(requests)-1]
+	require.Empty(t, req.Raw)
+	require.Empty(t, req.Opts.Headers.Get("Content-Type"))
+	require.Equal(t, "application/json", req.Opts.Headers.Get("Accept"))
+
+	_, err = httpc.DoJSONWithErr[reqSt, repSt, errRepSt](ctx, c, httpc.OptionsSt{Method: "PUT", Path: "obj"}, reqSt{})
+	require.ErrorIs(t, err, dopErrs.BadStatusCode)
+
+	var jsonErr *httpc.JSONErrorSt[errRepSt]
+	require.True(t, errors.As(err, &jsonErr))
+	require.True(t, jsonErr.Decoded)
+	require.Equal(t, 400, jsonErr.StatusCode)
+	require.Equal(t, "bad_name", jsonErr.ErrRep.ErrorCode)
+
+	// status is not in errStatuses
+	_, err = httpc.DoJSONWithErr[any, repSt, errRepSt](ctx, c, httpc.OptionsSt{Method: "PUT", Path: "obj"}, nil, 404)
+	require.False(t, errors.As(err, &jsonErr))
+
+	_, err = httpc.DoJSONWithErr[any, repSt, errRepSt](ctx, c, httpc.OptionsSt{Method: "DELETE", Path: "obj"}, nil)
+	require.True(t, errors.As(err, &jsonErr))
+	require.False(t, jsonErr.Decoded)
+	require.Equal(t, "oops", string(jsonErr.Body))
+}
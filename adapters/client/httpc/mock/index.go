@@ -1,9 +1,14 @@
 package mock
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/supernova0730/dop/adapters/client/httpc"
 	"github.com/supernova0730/dop/adapters/logger"
@@ -15,10 +20,12 @@ const (
 )
 
 type St struct {
-	lg logger.Lite
+	lg   logger.Lite
+	opts httpc.OptionsSt
 
 	requests  []*RequestSt
-	responses map[string]ResponseSt
+	responses map[string][]ResponseSt
+	counters  map[string]int
 	mu        sync.Mutex
 }
 
@@ -28,8 +35,12 @@ type RequestSt struct {
 }
 
 type ResponseSt struct {
-	Obj any
-	Raw []byte
+	StatusCode int
+	Headers    http.Header
+	Obj        any
+	Raw        []byte
+	Err        error
+	Delay      time.Duration
 }
 
 func New(lg logger.Lite) *St {
@@ -37,13 +48,22 @@ func New(lg logger.Lite) *St {
 		lg: lg,
 
 		requests:  []*RequestSt{},
-		responses: map[string]ResponseSt{},
+		responses: map[string][]ResponseSt{},
+		counters:  map[string]int{},
 	}
 }
 
+func (c *St) SetOptions(opts httpc.OptionsSt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts = opts
+}
+
 func (c *St) SetResponses(responses map[string]ResponseSt) {
 	c.mu.Lock()
-	c.responses = map[string]ResponseSt{}
+	c.responses = map[string][]ResponseSt{}
+	c.counters = map[string]int{}
 	c.mu.Unlock()
 
 	for k, v := range responses {
@@ -51,47 +71,195 @@ func (c *St) SetResponses(responses map[string]ResponseSt) {
 	}
 }
 
+// SetResponse sets response for path with any method
 func (c *St) SetResponse(path string, response ResponseSt) {
+	c.SetResponseSequence("", path, response)
+}
+
+func (c *St) SetMethodResponse(method, path string, response ResponseSt) {
+	c.SetResponseSequence(method, path, response)
+}
+
+// SetResponseSequence sets responses, which are returned one by one for each request.
+// The last one is repeated when the sequence is over.
+func (c *St) SetResponseSequence(method, path string, responses ...ResponseSt) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if len(response.Raw) == 0 && response.Obj != nil {
-		var err error
+	key := c.responseKey(method, path)
 
-		response.Raw, err = json.Marshal(response.Obj)
-		if err != nil {
-			c.lg.Errorw("Fail to marshal json", err)
+	seq := make([]ResponseSt, 0, len(responses))
+
+	for _, response := range responses {
+		if len(response.Raw) == 0 && response.Obj != nil {
+			var err error
+
+			response.Raw, err = json.Marshal(response.Obj)
+			if err != nil {
+				c.lg.Errorw("Fail to marshal json", err)
+			}
 		}
+
+		seq = append(seq, response)
 	}
 
-	c.responses[path] = response
+	c.responses[key] = seq
+	c.counters[key] = 0
 }
 
-func (c *St) GetOptions() httpc.OptionsSt {
-	return httpc.OptionsSt{}
+func (c *St) responseKey(method, path string) string {
+	if method == "" {
+		return path
+	}
+
+	return method + " " + path
+}
+
+func (c *St) pullResponse(method, path string) (ResponseSt, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := c.responseKey(method, path)
+
+	seq, ok := c.responses[key]
+	if !ok {
+		key = path
+		seq, ok = c.responses[key]
+	}
+	if !ok || len(seq) == 0 {
+		return ResponseSt{}, false
+	}
+
+	idx := c.counters[key]
+	if idx >= len(seq) {
+		idx = len(seq) - 1
+	} else {
+		c.counters[key] = idx + 1
+	}
+
+	return seq[idx], true
 }
 
-func (c *St) Send(reqBody []byte, opts httpc.OptionsSt) ([]byte, error) {
+func (c *St) GetOptions() httpc.OptionsSt {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	request := &RequestSt{
+	return c.opts
+}
+
+func (c *St) Send(reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendCtx(context.Background(), reqBody, opts)
+}
+
+func (c *St) SendCtx(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) ([]byte, int, error) {
+	rep, err := c.DoCtx(ctx, reqBody, opts)
+
+	return rep.Body, rep.StatusCode, httpc.UnwrapError(err)
+}
+
+func (c *St) Do(reqBody []byte, opts httpc.OptionsSt) (*httpc.ResponseSt, error) {
+	return c.DoCtx(context.Background(), reqBody, opts)
+}
+
+// DoCtx honours opts.RetryCount and retry policy like the real client, but does not wait between attempts
+func (c *St) DoCtx(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) (*httpc.ResponseSt, error) {
+	opts = c.GetOptions().GetMergedWith(opts)
+
+	retryPolicy := opts.GetRetryPolicy()
+	startTime := time.Now()
+
+	var err error
+	var response ResponseSt
+
+	rep := &httpc.ResponseSt{}
+
+	for attempt := 1; ; attempt++ {
+		response, rep.StatusCode, err = c.pullScriptedResponse(ctx, reqBody, opts)
+		rep.Header = response.Headers
+		rep.Body = response.Raw
+		rep.Attempts = attempt
+		if err == nil || attempt > opts.RetryCount || ctx.Err() != nil {
+			break
+		}
+
+		if _, ok := retryPolicy.Delay(httpc.RetryStateSt{
+			Attempt:    attempt,
+			Elapsed:    time.Since(startTime),
+			Method:     opts.Method,
+			StatusCode: rep.StatusCode,
+			Header:     rep.Header,
+			Err:        err,
+		}); !ok {
+			break
+		}
+	}
+
+	rep.Duration = time.Since(startTime)
+
+	if err != nil {
+		return rep, httpc.NewError(rep, err)
+	}
+
+	return rep, nil
+}
+
+func (c *St) pullScriptedResponse(ctx context.Context, reqBody []byte, opts httpc.OptionsSt) (ResponseSt, int, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, &RequestSt{
 		Opts: opts,
 		Raw:  reqBody,
+	})
+	c.mu.Unlock()
+
+	response, ok := c.pullResponse(opts.Method, opts.Path)
+	if !ok {
+		c.lg.Infow("Httpc-mock, path not found", "method", opts.Method, "path", opts.Path)
+		return ResponseSt{}, 0, ErrPageNotFound
 	}
 
-	c.requests = append(c.requests, request)
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
 
-	response, ok := c.responses[opts.Path]
-	if !ok {
-		c.lg.Infow("Httpc-mock, path not found", "path", opts.Path)
-		return nil, ErrPageNotFound
+		select {
+		case <-ctx.Done():
+			return ResponseSt{}, 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if response.Err != nil {
+		return ResponseSt{}, response.StatusCode, response.Err
+	}
+
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	if opts.MaxResponseSize > 0 && int64(len(response.Raw)) > opts.MaxResponseSize {
+		return ResponseSt{}, statusCode, httpc.ErrResponseTooLarge
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		switch statusCode {
+		case http.StatusUnauthorized:
+			return response, statusCode, dopErrs.NotAuthorized
+		case http.StatusForbidden:
+			return response, statusCode, dopErrs.PermissionDenied
+		default:
+			return response, statusCode, dopErrs.BadStatusCode
+		}
 	}
 
-	return response.Raw, nil
+	return response, statusCode, nil
+}
+
+func (c *St) SendJson(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendJsonCtx(context.Background(), reqObj, opts)
 }
 
-func (c *St) SendJson(reqObj any, opts httpc.OptionsSt) ([]byte, error) {
+func (c *St) SendJsonCtx(ctx context.Context, reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
 	if opts.Headers == nil {
 		opts.Headers = http.Header{}
 	}
@@ -100,42 +268,77 @@ func (c *St) SendJson(reqObj any, opts httpc.OptionsSt) ([]byte, error) {
 
 	reqBody, err := json.Marshal(reqObj)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	repBody, err := c.Send(reqBody, opts)
+	return c.SendCtx(ctx, reqBody, opts)
+}
+
+func (c *St) SendForm(reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendFormCtx(context.Background(), reqObj, opts)
+}
+
+func (c *St) SendFormCtx(ctx context.Context, reqObj any, opts httpc.OptionsSt) ([]byte, int, error) {
+	opts.Headers = headersWithContentType(opts.Headers, "application/x-www-form-urlencoded")
+
+	return c.SendCtx(ctx, httpc.EncodeForm(reqObj), opts)
+}
+
+func (c *St) SendMultipart(fields any, files []httpc.MultipartFileSt, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendMultipartCtx(context.Background(), fields, files, opts)
+}
+
+func (c *St) SendMultipartCtx(ctx context.Context, fields any, files []httpc.MultipartFileSt, opts httpc.OptionsSt) ([]byte, int, error) {
+	reqBody, contentType, err := httpc.EncodeMultipart(fields, files)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return repBody, nil
+	opts.Headers = headersWithContentType(opts.Headers, contentType)
+
+	return c.SendCtx(ctx, reqBody, opts)
+}
+
+func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendRecvJsonCtx(context.Background(), reqBody, repObj, statusRepObj, opts)
 }
 
-func (c *St) SendRecvJson(reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, error) {
+func (c *St) SendRecvJsonCtx(ctx context.Context, reqBody []byte, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
 	if opts.Headers == nil {
 		opts.Headers = http.Header{}
 	}
 
 	opts.Headers["Accept"] = []string{"application/json"}
 
-	repBody, err := c.Send(reqBody, opts)
+	repBody, statusCode, err := c.SendCtx(ctx, reqBody, opts)
 	if err != nil {
-		return nil, err
+		if err != dopErrs.BadStatusCode && err != dopErrs.NotAuthorized && err != dopErrs.PermissionDenied {
+			return repBody, statusCode, err
+		}
 	}
 
 	if len(repBody) > 0 {
-		if repObj != nil {
-			err = json.Unmarshal(repBody, repObj)
-			if err != nil {
-				return nil, err
+		if err == nil {
+			if repObj != nil {
+				err = json.Unmarshal(repBody, repObj)
+			}
+		} else if statusCode > 0 {
+			if statusRepObj != nil {
+				if rObj, ok := statusRepObj[statusCode]; ok {
+					err = json.Unmarshal(repBody, rObj)
+				}
 			}
 		}
 	}
 
-	return repBody, nil
+	return repBody, statusCode, err
 }
 
-func (c *St) SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, error) {
+func (c *St) SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
+	return c.SendJsonRecvJsonCtx(context.Background(), reqObj, repObj, statusRepObj, opts)
+}
+
+func (c *St) SendJsonRecvJsonCtx(ctx context.Context, reqObj, repObj any, statusRepObj map[int]any, opts httpc.OptionsSt) ([]byte, int, error) {
 	if opts.Headers == nil {
 		opts.Headers = http.Header{}
 	}
@@ -144,10 +347,41 @@ func (c *St) SendJsonRecvJson(reqObj, repObj any, statusRepObj map[int]any, opts
 
 	reqBody, err := json.Marshal(reqObj)
 	if err != nil {
+		return nil, 0, err
+	}
+
+	return c.SendRecvJsonCtx(ctx, reqBody, repObj, statusRepObj, opts)
+}
+
+func (c *St) SendStream(reqBody io.Reader, opts httpc.OptionsSt) (*httpc.StreamRepSt, error) {
+	return c.SendStreamCtx(context.Background(), reqBody, opts)
+}
+
+func (c *St) SendStreamCtx(ctx context.Context, reqBody io.Reader, opts httpc.OptionsSt) (*httpc.StreamRepSt, error) {
+	opts = c.GetOptions().GetMergedWith(opts)
+	opts.MaxResponseSize = 0
+
+	var raw []byte
+
+	if reqBody != nil {
+		var err error
+
+		raw, err = ioutil.ReadAll(reqBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response, statusCode, err := c.pullScriptedResponse(ctx, raw, opts)
+	if statusCode == 0 {
 		return nil, err
 	}
 
-	return c.SendRecvJson(reqBody, repObj, statusRepObj, opts)
+	return &httpc.StreamRepSt{
+		StatusCode: statusCode,
+		Header:     response.Headers,
+		Body:       ioutil.NopCloser(bytes.NewReader(response.Raw)),
+	}, err
 }
 
 func (c *St) GetRequests() []*RequestSt {
@@ -191,5 +425,17 @@ func (c *St) Clean() {
 	defer c.mu.Unlock()
 
 	c.requests = []*RequestSt{}
-	c.responses = map[string]ResponseSt{}
+	c.responses = map[string][]ResponseSt{}
+	c.counters = map[string]int{}
+}
+
+// headersWithContentType copies headers, not to modify caller's map
+func headersWithContentType(headers http.Header, contentType string) http.Header {
+	result := make(http.Header, len(headers)+1)
+	for k, v := range headers {
+		result[k] = v
+	}
+	result["Content-Type"] = []string{contentType}
+
+	return result
 }
@@ -0,0 +1,117 @@
+package mock
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+var _ httpc.HttpC = (*St)(nil)
+
+func TestSendRecvJson(t *testing.T) {
+	c := New(zap.New("info", true))
+
+	type repSt struct {
+		V int `json:"v"`
+	}
+
+	type errRepSt struct {
+		ErrorCode string `json:"error_code"`
+	}
+
+	c.SetMethodResponse("GET", "obj", ResponseSt{Obj: repSt{V: 7}})
+	c.SetMethodResponse("POST", "obj", ResponseSt{
+		StatusCode: 400,
+		Obj:        errRepSt{ErrorCode: "bad_obj"},
+	})
+
+	repObj := repSt{}
+	_, statusCode, err := c.SendRecvJson(nil, &repObj, nil, httpc.OptionsSt{Method: "GET", Path: "obj"})
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	require.Equal(t, 7, repObj.V)
+
+	errRepObj := errRepSt{}
+	_, statusCode, err = c.SendRecvJson(nil, nil, map[int]any{400: &errRepObj}, httpc.OptionsSt{Method: "POST", Path: "obj"})
+	require.NoError(t, err) // same as real client: decoded status-object resets the error
+	require.Equal(t, 400, statusCode)
+	require.Equal(t, "bad_obj", errRepObj.ErrorCode)
+
+	_, _, err = c.Send(nil, httpc.OptionsSt{Method: "GET", Path: "unknown"})
+	require.Equal(t, ErrPageNotFound, err)
+}
+
+func TestSendForm(t *testing.T) {
+	c := New(zap.New("info", true))
+	c.SetMethodResponse("POST", "form", ResponseSt{})
+
+	headers := http.Header{"X-Test": {"1"}}
+
+	_, _, err := c.SendForm(url.Values{"a": {"1"}}, httpc.OptionsSt{Method: "POST", Path: "form", Headers: headers})
+	require.NoError(t, err)
+	_, _, err = c.SendMultipart(url.Values{"a": {"1"}}, nil, httpc.OptionsSt{Method: "POST", Path: "form", Headers: headers})
+	require.NoError(t, err)
+
+	require.Equal(t, http.Header{"X-Test": {"1"}}, headers)
+}
+
+func TestSendSequence(t *testing.T) {
+	c := New(zap.New("info", true))
+
+	c.SetResponseSequence("GET", "send",
+		ResponseSt{StatusCode: 503},
+		ResponseSt{StatusCode: 503},
+		ResponseSt{StatusCode: 401},
+		ResponseSt{Raw: []byte("ok")},
+	)
+
+	_, statusCode, err := c.Send(nil, httpc.OptionsSt{Method: "GET", Path: "send"})
+	require.Equal(t, dopErrs.BadStatusCode, err)
+	require.Equal(t, 503, statusCode)
+
+	// 503 is retried, 401 is not, like in the real client
+	_, statusCode, err = c.Send(nil, httpc.OptionsSt{Method: "GET", Path: "send", RetryCount: 2})
+	require.Equal(t, dopErrs.NotAuthorized, err)
+	require.Equal(t, 401, statusCode)
+
+	repBody, statusCode, err := c.Send(nil, httpc.OptionsSt{Method: "GET", Path: "send"})
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	require.Equal(t, "ok", string(repBody))
+
+	// the last response is repeated
+	_, _, err = c.Send(nil, httpc.OptionsSt{Method: "GET", Path: "send"})
+	require.NoError(t, err)
+
+	require.Len(t, c.GetRequests(), 5)
+
+	// non-idempotent request is not retried on 503
+	c.SetResponseSequence("POST", "send",
+		ResponseSt{StatusCode: 503},
+		ResponseSt{Raw: []byte("ok")},
+	)
+
+	_, statusCode, err = c.Send(nil, httpc.OptionsSt{Method: "POST", Path: "send", RetryCount: 1})
+	require.Equal(t, dopErrs.BadStatusCode, err)
+	require.Equal(t, 503, statusCode)
+	require.Len(t, c.GetRequests(), 6)
+}
+
+func TestSendDelay(t *testing.T) {
+	c := New(zap.New("info", true))
+
+	c.SetResponse("slow", ResponseSt{Delay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := c.SendCtx(ctx, nil, httpc.OptionsSt{Method: "GET", Path: "slow"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
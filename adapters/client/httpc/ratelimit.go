@@ -0,0 +1,161 @@
+package httpc
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type RateLimitSt struct {
+	Rate  float64       // requests per Per, 0 - unlimited
+	Per   time.Duration // default 1 second
+	Burst int           // default - max(1, Rate)
+}
+
+type RateLimitOptionsSt struct {
+	RateLimitSt                        // whole client
+	Paths       map[string]RateLimitSt // by OptionsSt.Path, applied in addition to client limit
+	FailFast    bool                   // return ErrRateLimited instead of waiting
+	MaxWait     time.Duration          // return ErrRateLimited if wait is longer, 0 - unlimited
+}
+
+type RateLimitStatsSt struct {
+	Waited   int64 // requests delayed by limiter
+	Rejected int64 // requests failed with ErrRateLimited
+}
+
+// RateLimiterSt is a token bucket limiter, tokens are reserved in advance,
+// so concurrent callers are served in order of arrival
+type RateLimiterSt struct {
+	opts RateLimitOptionsSt
+	now  func() time.Time
+
+	client *tokenBucketSt
+	paths  map[string]*tokenBucketSt
+	stats  RateLimitStatsSt
+	mu     sync.Mutex
+}
+
+type tokenBucketSt struct {
+	rate   float64 // per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(opts RateLimitOptionsSt) *RateLimiterSt {
+	l := &RateLimiterSt{
+		opts:  opts,
+		now:   time.Now,
+		paths: map[string]*tokenBucketSt{},
+	}
+
+	l.client = newTokenBucket(opts.RateLimitSt)
+	for path, limit := range opts.Paths {
+		l.paths[path] = newTokenBucket(limit)
+	}
+
+	return l
+}
+
+func newTokenBucket(limit RateLimitSt) *tokenBucketSt {
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	per := limit.Per
+	if per <= 0 {
+		per = time.Second
+	}
+
+	burst := float64(limit.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Floor(limit.Rate))
+	}
+
+	return &tokenBucketSt{
+		rate:   limit.Rate / per.Seconds(),
+		burst:  burst,
+		tokens: burst,
+	}
+}
+
+// reserve takes token (the balance may become negative) and returns time to wait for it
+func (b *tokenBucketSt) reserve(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucketSt) cancel() {
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// Wait blocks until request to path is allowed
+func (l *RateLimiterSt) Wait(ctx context.Context, path string) error {
+	l.mu.Lock()
+
+	now := l.now()
+
+	buckets := make([]*tokenBucketSt, 0, 2)
+	if l.client != nil {
+		buckets = append(buckets, l.client)
+	}
+	if b := l.paths[path]; b != nil {
+		buckets = append(buckets, b)
+	}
+
+	var wait time.Duration
+
+	for _, b := range buckets {
+		if d := b.reserve(now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	if l.opts.FailFast || (l.opts.MaxWait > 0 && wait > l.opts.MaxWait) {
+		for _, b := range buckets {
+			b.cancel()
+		}
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+
+	l.stats.Waited++
+
+	l.mu.Unlock()
+
+	if !SleepCtx(ctx, wait) {
+		l.mu.Lock()
+		for _, b := range buckets {
+			b.cancel()
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func (l *RateLimiterSt) Stats() RateLimitStatsSt {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.stats
+}
@@ -0,0 +1,85 @@
+package httpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketSt(t *testing.T) {
+	ts := time.Now()
+
+	b := newTokenBucket(RateLimitSt{Rate: 2, Burst: 2})
+
+	require.Zero(t, b.reserve(ts))
+	require.Zero(t, b.reserve(ts))
+	require.Equal(t, 500*time.Millisecond, b.reserve(ts))
+	require.Equal(t, time.Second, b.reserve(ts))
+
+	b.cancel()
+	b.cancel()
+
+	ts = ts.Add(time.Second)
+	require.Zero(t, b.reserve(ts))
+	require.Zero(t, b.reserve(ts))
+
+	// tokens are not accumulated over burst
+	ts = ts.Add(time.Hour)
+	require.Zero(t, b.reserve(ts))
+	require.Zero(t, b.reserve(ts))
+	require.Equal(t, 500*time.Millisecond, b.reserve(ts))
+
+	b = newTokenBucket(RateLimitSt{Rate: 1, Per: time.Minute})
+	require.Zero(t, b.reserve(ts))
+	require.Equal(t, time.Minute, b.reserve(ts))
+
+	require.Nil(t, newTokenBucket(RateLimitSt{}))
+}
+
+func TestRateLimiterSt(t *testing.T) {
+	ts := time.Now()
+
+	l := NewRateLimiter(RateLimitOptionsSt{
+		RateLimitSt: RateLimitSt{Rate: 10},
+		Paths:       map[string]RateLimitSt{"send": {Rate: 1}},
+		FailFast:    true,
+	})
+	l.now = func() time.Time { return ts }
+
+	ctx := context.Background()
+
+	require.NoError(t, l.Wait(ctx, "send"))
+	require.Equal(t, ErrRateLimited, l.Wait(ctx, "send"))
+	// rejected request does not consume client tokens
+	for i := 0; i < 9; i++ {
+		require.NoError(t, l.Wait(ctx, "status"))
+	}
+	require.Equal(t, ErrRateLimited, l.Wait(ctx, "status"))
+	require.Equal(t, RateLimitStatsSt{Rejected: 2}, l.Stats())
+
+	l = NewRateLimiter(RateLimitOptionsSt{
+		RateLimitSt: RateLimitSt{Rate: 1, Per: time.Hour},
+	})
+
+	require.NoError(t, l.Wait(ctx, ""))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	require.Equal(t, context.DeadlineExceeded, l.Wait(ctx, ""))
+	require.Equal(t, RateLimitStatsSt{Waited: 1}, l.Stats())
+
+	l = NewRateLimiter(RateLimitOptionsSt{
+		RateLimitSt: RateLimitSt{Rate: 100, Burst: 1},
+		MaxWait:     time.Second,
+	})
+
+	startTime := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Wait(context.Background(), ""))
+	}
+	require.GreaterOrEqual(t, time.Since(startTime), 15*time.Millisecond)
+	require.Equal(t, RateLimitStatsSt{Waited: 2}, l.Stats())
+}
@@ -0,0 +1,265 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var DefaultRedactOptions = RedactOptionsSt{
+	Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+}
+
+// RedactOptionsSt describes what is masked in logs, nil is same as DefaultRedactOptions
+type RedactOptionsSt struct {
+	Headers []string // case-insensitive header names, added to DefaultRedactOptions.Headers
+	// NoDefaultHeaders disables masking of DefaultRedactOptions.Headers, e.g. to debug auth
+	NoDefaultHeaders bool
+	// JsonFields - field name without dots is masked at any depth,
+	// dotted path is matched from root, "*" matches any key, arrays are skipped: "user.password", "items.card", "meta.*"
+	JsonFields []string
+	Params     []string // query and form params
+	MaxBodyLen int      // bodies are truncated in logs, 0 - unlimited
+	Mask       string   // default "***"
+}
+
+func (o *RedactOptionsSt) get() *RedactOptionsSt {
+	if o == nil {
+		return &DefaultRedactOptions
+	}
+	return o
+}
+
+func (o *RedactOptionsSt) mask() string {
+	if o.Mask == "" {
+		return "***"
+	}
+	return o.Mask
+}
+
+func (o *RedactOptionsSt) RedactHeaders(h http.Header) http.Header {
+	o = o.get()
+
+	if h == nil {
+		return nil
+	}
+
+	res := make(http.Header, len(h))
+
+	for k, v := range h {
+		if stringSliceHasFold(o.Headers, k) || (!o.NoDefaultHeaders && stringSliceHasFold(DefaultRedactOptions.Headers, k)) {
+			res[k] = []string{o.mask()}
+		} else {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+// RedactParams masks params in url-encoded string
+func (o *RedactOptionsSt) RedactParams(query string) string {
+	o = o.get()
+
+	if query == "" || len(o.Params) == 0 {
+		return query
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+
+	return o.redactValues(values).Encode()
+}
+
+func (o *RedactOptionsSt) RedactValues(values url.Values) url.Values {
+	return o.get().redactValues(values)
+}
+
+func (o *RedactOptionsSt) redactValues(values url.Values) url.Values {
+	if len(o.Params) == 0 {
+		return values
+	}
+
+	res := make(url.Values, len(values))
+
+	for k, v := range values {
+		if stringSliceHasFold(o.Params, k) {
+			res[k] = []string{o.mask()}
+		} else {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+// RedactBody returns body for logs: masked and truncated text, for binary content types - only its size
+func (o *RedactOptionsSt) RedactBody(contentType string, body []byte) string {
+	o = o.get()
+
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType := parseMediaType(contentType)
+
+	var res string
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		res = o.RedactParams(string(body))
+	case isJson(mediaType, body):
+		res = o.redactJson(body)
+	case mediaType == "" && utf8.Valid(body):
+		res = string(body)
+	case strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "application/xml" || mediaType == "application/javascript":
+		res = string(body)
+	default:
+		if contentType == "" {
+			return "<" + strconv.Itoa(len(body)) + " bytes>"
+		}
+		return "<" + strconv.Itoa(len(body)) + " bytes of " + contentType + ">"
+	}
+
+	if o.MaxBodyLen > 0 && len(res) > o.MaxBodyLen {
+		cut := o.MaxBodyLen
+		for cut > 0 && !utf8.RuneStart(res[cut]) {
+			cut--
+		}
+		res = res[:cut] + "...(truncated, " + strconv.Itoa(len(body)) + " bytes)"
+	}
+
+	return res
+}
+
+// RedactContent masks Params and JsonFields in form and json bodies, other bodies are returned as is.
+// Unlike RedactBody, the result is not truncated, e.g. for storing
+func (o *RedactOptionsSt) RedactContent(contentType string, body []byte) []byte {
+	o = o.get()
+
+	if len(body) == 0 {
+		return body
+	}
+
+	mediaType := parseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return []byte(o.RedactParams(string(body)))
+	case isJson(mediaType, body):
+		return []byte(o.redactJson(body))
+	}
+
+	return body
+}
+
+func parseMediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return mediaType
+}
+
+// isJson detects json also by body if content type is not set
+func isJson(mediaType string, body []byte) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		(mediaType == "" && utf8.Valid(body) && json.Valid(body))
+}
+
+func (o *RedactOptionsSt) redactJson(body []byte) string {
+	if len(o.JsonFields) == 0 {
+		return string(body)
+	}
+
+	var obj any
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&obj); err != nil {
+		return string(body)
+	}
+
+	paths := make([][]string, len(o.JsonFields))
+	for i, f := range o.JsonFields {
+		paths[i] = strings.Split(f, ".")
+	}
+
+	obj = o.redactJsonValue(obj, nil, paths)
+
+	res, err := json.Marshal(obj)
+	if err != nil {
+		return string(body)
+	}
+
+	return string(res)
+}
+
+func (o *RedactOptionsSt) redactJsonValue(v any, path []string, paths [][]string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, x := range val {
+			kPath := append(path[:len(path):len(path)], k)
+			if jsonPathMatches(kPath, paths) {
+				val[k] = o.mask()
+			} else {
+				val[k] = o.redactJsonValue(x, kPath, paths)
+			}
+		}
+	case []any:
+		for i, x := range val {
+			val[i] = o.redactJsonValue(x, path, paths)
+		}
+	}
+
+	return v
+}
+
+func jsonPathMatches(path []string, paths [][]string) bool {
+	for _, p := range paths {
+		if len(p) == 1 {
+			if p[0] == path[len(path)-1] {
+				return true
+			}
+			continue
+		}
+
+		if len(p) != len(path) {
+			continue
+		}
+
+		matches := true
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stringSliceHasFold(sl []string, v string) bool {
+	for _, x := range sl {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package httpc
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactBody(t *testing.T) {
+	var defaultOpts *RedactOptionsSt
+
+	opts := &RedactOptionsSt{
+		JsonFields: []string{"password", "card.number", "items.cvv", "meta.*"},
+		Params:     []string{"secret"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        *RedactOptionsSt
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{"plain", defaultOpts, "", []byte("plain"), "plain"},
+		{"binary", defaultOpts, "", []byte{0xff, 0xfe}, "<2 bytes>"},
+		{"json", defaultOpts, "application/json; charset=utf-8", []byte(`{"a":1}`), `{"a":1}`},
+		{"problem_json", defaultOpts, "application/problem+json", []byte(`{}`), `{}`},
+		{"form", defaultOpts, "application/x-www-form-urlencoded", []byte("a=1"), "a=1"},
+		{"multipart", defaultOpts, "multipart/form-data; boundary=xxx", []byte("--xxx"), "<5 bytes of multipart/form-data; boundary=xxx>"},
+		{"image", defaultOpts, "image/png", []byte{1, 2, 3}, "<3 bytes of image/png>"},
+		{
+			"json_fields", opts, "application/json",
+			[]byte(`{"login":"a","password":"b","user":{"password":"c"},"card":{"number":"1","bank":"x"},"items":[{"cvv":1,"id":2}],"meta":{"a":1},"number":3}`),
+			`{"card":{"bank":"x","number":"***"},"items":[{"cvv":"***","id":2}],"login":"a","meta":{"a":"***"},"number":3,"password":"***","user":{"password":"***"}}`,
+		},
+		{"json_detected", opts, "", []byte(`[{"password":"b"}]`), `[{"password":"***"}]`},
+		{"form_params", opts, "application/x-www-form-urlencoded", []byte("a=1&secret=2"), "a=1&secret=%2A%2A%2A"},
+		{"truncate", &RedactOptionsSt{MaxBodyLen: 3}, "text/plain", []byte("abcdef"), "abc...(truncated, 6 bytes)"},
+		{"truncate_utf8", &RedactOptionsSt{MaxBodyLen: 3}, "text/plain", []byte("аб"), "а...(truncated, 4 bytes)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.opts.RedactBody(tt.contentType, tt.body))
+		})
+	}
+}
+
+func TestRedactContent(t *testing.T) {
+	opts := &RedactOptionsSt{
+		JsonFields: []string{"password"},
+		Params:     []string{"secret"},
+		MaxBodyLen: 3,
+	}
+
+	require.Equal(t, `{"login":"a","password":"***"}`, string(opts.RedactContent("application/json", []byte(`{"login":"a","password":"b"}`))))
+	require.Equal(t, "a=1&secret=%2A%2A%2A", string(opts.RedactContent("application/x-www-form-urlencoded", []byte("secret=2&a=1"))))
+	require.Equal(t, "plain text", string(opts.RedactContent("text/plain", []byte("plain text"))))
+	require.Equal(t, []byte{0xff, 0xfe}, opts.RedactContent("", []byte{0xff, 0xfe}))
+}
+
+func TestRedactHeadersParams(t *testing.T) {
+	var opts *RedactOptionsSt
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer token")
+	h.Set("Content-Type", "application/json")
+
+	redacted := opts.RedactHeaders(h)
+	require.Equal(t, "***", redacted.Get("Authorization"))
+	require.Equal(t, "application/json", redacted.Get("Content-Type"))
+	require.Equal(t, "Bearer token", h.Get("Authorization"))
+
+	require.Equal(t, "token=abc", opts.RedactParams("token=abc"))
+
+	opts = &RedactOptionsSt{Params: []string{"Token"}, Mask: "-"}
+	require.Equal(t, "a=1&token=-", opts.RedactParams("token=abc&a=1"))
+
+	// default headers are kept with custom options
+	opts = &RedactOptionsSt{JsonFields: []string{"password"}}
+	require.Equal(t, "***", opts.RedactHeaders(h).Get("Authorization"))
+
+	opts = &RedactOptionsSt{Headers: []string{"X-Api-Key"}}
+	h.Set("X-Api-Key", "key")
+	redacted = opts.RedactHeaders(h)
+	require.Equal(t, "***", redacted.Get("Authorization"))
+	require.Equal(t, "***", redacted.Get("X-Api-Key"))
+
+	opts = &RedactOptionsSt{NoDefaultHeaders: true}
+	require.Equal(t, "Bearer token", opts.RedactHeaders(h).Get("Authorization"))
+}
@@ -0,0 +1,155 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/supernova0730/dop/dopErrs"
+	"github.com/supernova0730/dop/dopTools"
+)
+
+var DefaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type RetryStateSt struct {
+	Attempt    int // number of failed attempt, starts from 1
+	Elapsed    time.Duration
+	Method     string
+	StatusCode int         // 0 - if response not received
+	Header     http.Header // response headers
+	Err        error
+}
+
+type RetryPolicySt struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64 // 0 or 1 - constant interval
+	Jitter          float64 // randomization factor in range [0, 1]
+	MaxElapsedTime  time.Duration
+
+	StatusCodes     []int // nil - DefaultRetryStatusCodes
+	NoNetworkErrors bool
+	// RetryNonIdempotent enables retries of POST, PATCH etc. like idempotent ones,
+	// otherwise they are retried only on connection errors, when request was not sent
+	RetryNonIdempotent bool
+	IgnoreRetryAfter   bool
+}
+
+func (p RetryPolicySt) Delay(state RetryStateSt) (time.Duration, bool) {
+	if !p.RetryNonIdempotent && !IsIdempotentMethod(state.Method) &&
+		(state.StatusCode > 0 || !isConnectError(state.Err)) {
+		return 0, false
+	}
+
+	if state.StatusCode > 0 {
+		// error with successful status, e.g. ErrResponseTooLarge, is not fixed by retry
+		if state.StatusCode >= 200 && state.StatusCode <= 299 {
+			return 0, false
+		}
+		statusCodes := p.StatusCodes
+		if statusCodes == nil {
+			statusCodes = DefaultRetryStatusCodes
+		}
+		if !dopTools.SliceHasValue(statusCodes, state.StatusCode) {
+			return 0, false
+		}
+	} else if p.NoNetworkErrors || !isNetworkError(state.Err) {
+		return 0, false
+	}
+
+	delay := p.InitialInterval
+
+	if p.Multiplier > 1 {
+		for i := 1; i < state.Attempt; i++ {
+			delay = time.Duration(float64(delay) * p.Multiplier)
+			if p.MaxInterval > 0 && delay >= p.MaxInterval {
+				break
+			}
+		}
+	}
+
+	if p.MaxInterval > 0 && delay > p.MaxInterval {
+		delay = p.MaxInterval
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		delta := p.Jitter * float64(delay)
+		delay = time.Duration(float64(delay) - delta + rand.Float64()*2*delta)
+	}
+
+	if !p.IgnoreRetryAfter && state.Header != nil {
+		if retryAfter, ok := ParseRetryAfter(state.Header.Get("Retry-After")); ok && retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+
+	if p.MaxElapsedTime > 0 && state.Elapsed+delay > p.MaxElapsedTime {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// isNetworkError is true for transport errors (*url.Error is net.Error too).
+// Context errors and dopErrs errors (e.g. ErrAuthTokenFetch with transport cause) are not retried
+func isNetworkError(err error) bool {
+	var dErr dopErrs.Err
+	var netErr net.Error
+
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &dErr) {
+		return false
+	}
+
+	return errors.As(err, &netErr)
+}
+
+// isConnectError is true if connection was not established, so request was not sent
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+
+	return isNetworkError(err) && errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func IsIdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// ParseRetryAfter parses value of "Retry-After" header, which is either delay-seconds or http-date
+func ParseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if ts, err := http.ParseTime(v); err == nil {
+		delay := time.Until(ts)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,144 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicySt_Delay(t *testing.T) {
+	dialErr := &url.Error{Op: "Post", URL: "http://example.com", Err: &net.OpError{
+		Op: "dial", Net: "tcp", Err: errors.New("connection refused"),
+	}}
+	readErr := &url.Error{Op: "Post", URL: "http://example.com", Err: &net.OpError{
+		Op: "read", Net: "tcp", Err: errors.New("connection reset by peer"),
+	}}
+
+	tests := []struct {
+		policy    RetryPolicySt
+		state     RetryStateSt
+		wantDelay time.Duration
+		wantOk    bool
+	}{
+		{ // network error
+			policy:    RetryPolicySt{InitialInterval: time.Second},
+			state:     RetryStateSt{Attempt: 1, Method: http.MethodGet, Err: readErr},
+			wantDelay: time.Second,
+			wantOk:    true,
+		},
+		{ // network errors disabled
+			policy: RetryPolicySt{InitialInterval: time.Second, NoNetworkErrors: true},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodGet, Err: dialErr},
+		},
+		{ // not network error
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodGet, Err: ErrAuthTokenFetch.WithCause(dialErr)},
+		},
+		{ // e.g. error of interceptor
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodGet, Err: errors.New("interceptor failed")},
+		},
+		{ // context error
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state: RetryStateSt{Attempt: 1, Method: http.MethodGet, Err: &url.Error{
+				Op: "Get", URL: "http://example.com", Err: context.DeadlineExceeded,
+			}},
+		},
+		{ // error with successful status
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodGet, StatusCode: 200, Err: ErrResponseTooLarge},
+		},
+		{ // 4xx is not retried
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodGet, StatusCode: 400},
+		},
+		{ // configured status codes
+			policy:    RetryPolicySt{InitialInterval: time.Second, StatusCodes: []int{409}},
+			state:     RetryStateSt{Attempt: 1, Method: http.MethodGet, StatusCode: 409},
+			wantDelay: time.Second,
+			wantOk:    true,
+		},
+		{ // non-idempotent
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodPost, StatusCode: 503},
+		},
+		{ // non-idempotent, request is sent
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state:  RetryStateSt{Attempt: 1, Method: http.MethodPost, Err: readErr},
+		},
+		{ // non-idempotent, request is not sent
+			policy:    RetryPolicySt{InitialInterval: time.Second},
+			state:     RetryStateSt{Attempt: 1, Method: http.MethodPost, Err: dialErr},
+			wantDelay: time.Second,
+			wantOk:    true,
+		},
+		{
+			policy:    RetryPolicySt{InitialInterval: time.Second, RetryNonIdempotent: true},
+			state:     RetryStateSt{Attempt: 1, Method: http.MethodPost, StatusCode: 503},
+			wantDelay: time.Second,
+			wantOk:    true,
+		},
+		{ // exponential
+			policy:    RetryPolicySt{InitialInterval: time.Second, Multiplier: 2},
+			state:     RetryStateSt{Attempt: 4, Method: http.MethodGet, StatusCode: 502},
+			wantDelay: 8 * time.Second,
+			wantOk:    true,
+		},
+		{ // max interval
+			policy:    RetryPolicySt{InitialInterval: time.Second, Multiplier: 2, MaxInterval: 5 * time.Second},
+			state:     RetryStateSt{Attempt: 10, Method: http.MethodGet, StatusCode: 502},
+			wantDelay: 5 * time.Second,
+			wantOk:    true,
+		},
+		{ // max elapsed time
+			policy: RetryPolicySt{InitialInterval: time.Second, MaxElapsedTime: 10 * time.Second},
+			state:  RetryStateSt{Attempt: 3, Method: http.MethodGet, Elapsed: 9500 * time.Millisecond},
+		},
+		{ // retry-after
+			policy: RetryPolicySt{InitialInterval: time.Second},
+			state: RetryStateSt{
+				Attempt:    1,
+				Method:     http.MethodGet,
+				StatusCode: 429,
+				Header:     http.Header{"Retry-After": {"7"}},
+			},
+			wantDelay: 7 * time.Second,
+			wantOk:    true,
+		},
+		{
+			policy: RetryPolicySt{InitialInterval: time.Second, IgnoreRetryAfter: true},
+			state: RetryStateSt{
+				Attempt:    1,
+				Method:     http.MethodGet,
+				StatusCode: 429,
+				Header:     http.Header{"Retry-After": {"7"}},
+			},
+			wantDelay: time.Second,
+			wantOk:    true,
+		},
+	}
+	for ttI, tt := range tests {
+		t.Run(strconv.Itoa(ttI+1), func(t *testing.T) {
+			gotDelay, gotOk := tt.policy.Delay(tt.state)
+			if gotDelay != tt.wantDelay || gotOk != tt.wantOk {
+				t.Errorf("Delay() = %v, %v, want %v, %v", gotDelay, gotOk, tt.wantDelay, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRetryPolicySt_DelayJitter(t *testing.T) {
+	policy := RetryPolicySt{InitialInterval: time.Second, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay, ok := policy.Delay(RetryStateSt{Attempt: 1, StatusCode: 503})
+		if !ok || delay < 500*time.Millisecond || delay > 1500*time.Millisecond {
+			t.Fatalf("Delay() = %v, %v, want in range [500ms, 1500ms]", delay, ok)
+		}
+	}
+}
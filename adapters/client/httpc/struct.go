@@ -1,28 +1,40 @@
 package httpc
 
 import (
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
 type OptionsSt struct {
-	Client         *http.Client
-	BaseUrl        string
-	BaseParams     url.Values
-	BaseHeaders    http.Header
-	BaseLogPrefix  string
-	BasicAuthCreds *BasicAuthCredsSt
-
-	Method        string
-	Path          string
-	Params        url.Values
-	Headers       http.Header
-	LogFlags      int
-	LogPrefix     string
-	RetryCount    int
-	RetryInterval time.Duration
-	Timeout       time.Duration
+	Client           *http.Client
+	BaseUrl          string
+	BaseParams       url.Values
+	BaseHeaders      http.Header
+	BaseLogPrefix    string
+	BaseInterceptors []Interceptor
+	BasicAuthCreds   *BasicAuthCredsSt
+	Auth             AuthProvider             // applied after BasicAuthCreds
+	CircuitBreaker   *CircuitBreakerOptionsSt // client-level, used only on client creation
+	RateLimit        *RateLimitOptionsSt      // client-level, used only on client creation
+	Cache            *CacheOptionsSt          // client-level, used only on client creation
+	Redact           *RedactOptionsSt         // nil - DefaultRedactOptions
+
+	Method          string
+	Path            string
+	Params          url.Values
+	Headers         http.Header
+	Interceptors    []Interceptor // executed after BaseInterceptors
+	LogFlags        int
+	LogPrefix       string
+	RetryCount      int
+	RetryInterval   time.Duration
+	RetryPolicy     RetryPolicy // nil - constant RetryInterval, see GetRetryPolicy
+	Timeout         time.Duration
+	MaxResponseSize int64         // bytes, for buffered responses, 0 - unlimited
+	CacheTtl        time.Duration // overrides freshness of cached GET response, see CacheOptionsSt
 }
 
 type BasicAuthCredsSt struct {
@@ -30,23 +42,116 @@ type BasicAuthCredsSt struct {
 	Password string
 }
 
+type ResponseSt struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration // total, including retries
+	Attempts   int
+	Cached     bool // served from cache, Attempts is 0 if request was not sent
+}
+
+type ErrorSt struct {
+	Err        error // dopErrs.BadStatusCode, dopErrs.NotAuthorized, dopErrs.PermissionDenied or any other error
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration
+	Attempts   int
+}
+
+func NewError(rep *ResponseSt, err error) *ErrorSt {
+	return &ErrorSt{
+		Err:        err,
+		StatusCode: rep.StatusCode,
+		Header:     rep.Header,
+		Body:       rep.Body,
+		Duration:   rep.Duration,
+		Attempts:   rep.Attempts,
+	}
+}
+
+func (e *ErrorSt) Error() string {
+	if e.StatusCode == 0 {
+		return e.Err.Error()
+	}
+
+	return e.Err.Error() + ", status_code:" + strconv.Itoa(e.StatusCode)
+}
+
+func (e *ErrorSt) Unwrap() error {
+	return e.Err
+}
+
+// External implements dopErrs.ExternalError
+func (e *ErrorSt) External() bool {
+	return true
+}
+
+// UnwrapError returns original error if err is *ErrorSt
+func UnwrapError(err error) error {
+	if e, ok := err.(*ErrorSt); ok {
+		return e.Err
+	}
+
+	return err
+}
+
+// WrapExternal marks error of Send* methods (see UnwrapError) as error of external service,
+// adapters return it, so that upstream 401/403 are not responded to their callers as is, see dopErrs.ExternalError
+func WrapExternal(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*ErrorSt); ok {
+		return err
+	}
+
+	return &ErrorSt{Err: err}
+}
+
+type MultipartFileSt struct {
+	FieldName   string
+	FileName    string
+	ContentType string    // default - application/octet-stream
+	Reader      io.Reader // read on request build, Data is used if nil
+	Data        []byte
+}
+
+type StreamRepSt struct {
+	StatusCode int
+	Header     http.Header
+	Body       io.ReadCloser
+}
+
 func (o OptionsSt) GetMergedWith(v OptionsSt) OptionsSt {
 	res := OptionsSt{
-		Client:         o.Client,
-		BaseUrl:        o.BaseUrl,
-		BaseParams:     o.BaseParams,
-		BaseHeaders:    o.BaseHeaders,
-		BaseLogPrefix:  o.BaseLogPrefix,
-		BasicAuthCreds: o.BasicAuthCreds,
-		Method:         o.Method,
-		Path:           o.Path,
-		Params:         o.Params,
-		Headers:        o.Headers,
-		LogFlags:       o.LogFlags,
-		LogPrefix:      o.LogPrefix,
-		RetryCount:     o.RetryCount,
-		RetryInterval:  o.RetryInterval,
-		Timeout:        o.Timeout,
+		Client:           o.Client,
+		BaseUrl:          o.BaseUrl,
+		BaseParams:       o.BaseParams,
+		BaseHeaders:      o.BaseHeaders,
+		BaseLogPrefix:    o.BaseLogPrefix,
+		BaseInterceptors: o.BaseInterceptors,
+		BasicAuthCreds:   o.BasicAuthCreds,
+		Auth:             o.Auth,
+		CircuitBreaker:   o.CircuitBreaker,
+		RateLimit:        o.RateLimit,
+		Cache:            o.Cache,
+		Redact:           o.Redact,
+		Method:           o.Method,
+		Path:             o.Path,
+		Params:           o.Params,
+		Headers:          o.Headers,
+		Interceptors:     o.Interceptors,
+		LogFlags:         o.LogFlags,
+		LogPrefix:        o.LogPrefix,
+		RetryCount:       o.RetryCount,
+		RetryInterval:    o.RetryInterval,
+		RetryPolicy:      o.RetryPolicy,
+		Timeout:          o.Timeout,
+		MaxResponseSize:  o.MaxResponseSize,
+		CacheTtl:         o.CacheTtl,
 	}
 
 	if v.Client != nil {
@@ -72,9 +177,27 @@ func (o OptionsSt) GetMergedWith(v OptionsSt) OptionsSt {
 			res.BaseLogPrefix = v.BaseLogPrefix
 		}
 	}
+	if v.BaseInterceptors != nil {
+		res.BaseInterceptors = v.BaseInterceptors
+	}
 	if v.BasicAuthCreds != nil {
 		res.BasicAuthCreds = v.BasicAuthCreds
 	}
+	if v.Auth != nil {
+		res.Auth = v.Auth
+	}
+	if v.CircuitBreaker != nil {
+		res.CircuitBreaker = v.CircuitBreaker
+	}
+	if v.RateLimit != nil {
+		res.RateLimit = v.RateLimit
+	}
+	if v.Cache != nil {
+		res.Cache = v.Cache
+	}
+	if v.Redact != nil {
+		res.Redact = v.Redact
+	}
 	if v.Method != "" {
 		if v.Method == "-" {
 			res.Method = ""
@@ -95,6 +218,9 @@ func (o OptionsSt) GetMergedWith(v OptionsSt) OptionsSt {
 	if v.Headers != nil {
 		res.Headers = v.Headers
 	}
+	if v.Interceptors != nil {
+		res.Interceptors = v.Interceptors
+	}
 	if v.LogFlags != 0 {
 		if v.LogFlags < 0 {
 			res.LogFlags = 0
@@ -123,6 +249,9 @@ func (o OptionsSt) GetMergedWith(v OptionsSt) OptionsSt {
 			res.RetryInterval = v.RetryInterval
 		}
 	}
+	if v.RetryPolicy != nil {
+		res.RetryPolicy = v.RetryPolicy
+	}
 	if v.Timeout != 0 {
 		if v.Timeout < 0 {
 			res.Timeout = 0
@@ -130,6 +259,30 @@ func (o OptionsSt) GetMergedWith(v OptionsSt) OptionsSt {
 			res.Timeout = v.Timeout
 		}
 	}
+	if v.MaxResponseSize != 0 {
+		if v.MaxResponseSize < 0 {
+			res.MaxResponseSize = 0
+		} else {
+			res.MaxResponseSize = v.MaxResponseSize
+		}
+	}
+	if v.CacheTtl != 0 {
+		if v.CacheTtl < 0 {
+			res.CacheTtl = 0
+		} else {
+			res.CacheTtl = v.CacheTtl
+		}
+	}
 
 	return res
 }
+
+func (o OptionsSt) GetRetryPolicy() RetryPolicy {
+	if o.RetryPolicy != nil {
+		return o.RetryPolicy
+	}
+
+	return RetryPolicySt{
+		InitialInterval: o.RetryInterval,
+	}
+}
@@ -1,12 +1,21 @@
 package httpc
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 )
 
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
 func Object2UrlValues(obj any) url.Values {
 	result := url.Values{}
 
@@ -55,3 +64,96 @@ func Object2UrlValues(obj any) url.Values {
 
 	return result
 }
+
+// EncodeForm encodes url.Values or struct with "form" tags as application/x-www-form-urlencoded
+func EncodeForm(obj any) []byte {
+	switch v := obj.(type) {
+	case nil:
+		return nil
+	case url.Values:
+		return []byte(v.Encode())
+	case map[string][]string:
+		return []byte(url.Values(v).Encode())
+	default:
+		return []byte(Object2UrlValues(obj).Encode())
+	}
+}
+
+// EncodeMultipart encodes fields (same as in EncodeForm) and files as multipart/form-data, returns body and content-type
+func EncodeMultipart(fields any, files []MultipartFileSt) ([]byte, string, error) {
+	var values url.Values
+
+	switch v := fields.(type) {
+	case nil:
+	case url.Values:
+		values = v
+	case map[string][]string:
+		values = v
+	default:
+		values = Object2UrlValues(fields)
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if err := w.WriteField(k, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(
+			`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(file.FieldName),
+			quoteEscaper.Replace(file.FileName),
+		))
+		if file.ContentType != "" {
+			header.Set("Content-Type", file.ContentType)
+		} else {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if file.Reader != nil {
+			_, err = io.Copy(pw, file.Reader)
+		} else {
+			_, err = pw.Write(file.Data)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return body.Bytes(), w.FormDataContentType(), nil
+}
+
+// SleepCtx returns false if ctx is done before d elapsed
+func SleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -1,9 +1,14 @@
 package httpc
 
 import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/supernova0730/dop/dopTools"
@@ -62,3 +67,66 @@ func TestObject2UrlValues(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeMultipart(t *testing.T) {
+	fields := struct {
+		Name string   `form:"name"`
+		Tags []string `form:"tags"`
+	}{
+		Name: "report",
+		Tags: []string{"a", "b"},
+	}
+
+	body, contentType, err := EncodeMultipart(fields, []MultipartFileSt{
+		{FieldName: "file", FileName: "r.csv", ContentType: "text/csv", Reader: strings.NewReader("1,2,3")},
+		{FieldName: "raw", FileName: "r.bin", Data: []byte{0, 1, 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("bad content-type %q", contentType)
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(form.Value, map[string][]string{"name": {"report"}, "tags": {"a", "b"}}) {
+		t.Errorf("form values = %v", form.Value)
+	}
+
+	wantFiles := map[string]struct {
+		fileName    string
+		contentType string
+		data        []byte
+	}{
+		"file": {"r.csv", "text/csv", []byte("1,2,3")},
+		"raw":  {"r.bin", "application/octet-stream", []byte{0, 1, 2}},
+	}
+
+	for field, want := range wantFiles {
+		if len(form.File[field]) != 1 {
+			t.Fatalf("file %q not found", field)
+		}
+
+		fh := form.File[field][0]
+		if fh.Filename != want.fileName || fh.Header.Get("Content-Type") != want.contentType {
+			t.Errorf("file %q: name = %q, content-type = %q", field, fh.Filename, fh.Header.Get("Content-Type"))
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(f)
+		_ = f.Close()
+
+		if !bytes.Equal(data, want.data) {
+			t.Errorf("file %q: data = %v, want %v", field, data, want.data)
+		}
+	}
+}
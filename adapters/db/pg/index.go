@@ -55,6 +55,16 @@ func New(debug bool, lg logger.WarnAndError, opts OptionsSt) (*St, error) {
 	}, nil
 }
 
+// Close closes all connections, waits for acquired ones to be released
+func (d *St) Close() {
+	d.Con.Close()
+}
+
+// Check pings db, implements health.Checker
+func (d *St) Check(ctx context.Context) error {
+	return d.Con.Ping(ctx)
+}
+
 func (d *St) getCon(ctx context.Context) db.RDBConSt {
 	if tx := d.getContextTransaction(ctx); tx != nil {
 		return tx
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOk   = "ok"
+	StatusFail = "fail"
+
+	DefaultTimeout = 5 * time.Second
+)
+
+type RepSt struct {
+	Status     string                     `json:"status"`
+	Components map[string]*ComponentRepSt `json:"components,omitempty"`
+}
+
+type ComponentRepSt struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Check runs checkers in parallel, each one is limited by timeout (DefaultTimeout if 0)
+func Check(ctx context.Context, checkers map[string]Checker, timeout time.Duration) *RepSt {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	result := &RepSt{
+		Status:     StatusOk,
+		Components: make(map[string]*ComponentRepSt, len(checkers)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for name, checker := range checkers {
+		wg.Add(1)
+
+		go func(name string, checker Checker) {
+			defer wg.Done()
+
+			rep := check(ctx, checker, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			result.Components[name] = rep
+			if rep.Status != StatusOk {
+				result.Status = StatusFail
+			}
+		}(name, checker)
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+func check(ctx context.Context, checker Checker, timeout time.Duration) *ComponentRepSt {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startTime := time.Now()
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- checker.Check(ctx)
+	}()
+
+	var err error
+
+	// checker may ignore ctx
+	select {
+	case err = <-errChan:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &ComponentRepSt{
+		Status:     StatusOk,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
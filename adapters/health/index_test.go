@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	sleep := func(d time.Duration) Checker {
+		return CheckerFunc(func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return errors.New("interrupted")
+			case <-time.After(d):
+				return nil
+			}
+		})
+	}
+
+	rep := Check(context.Background(), map[string]Checker{}, 0)
+	require.Equal(t, StatusOk, rep.Status)
+	require.Empty(t, rep.Components)
+
+	startTime := time.Now()
+
+	rep = Check(context.Background(), map[string]Checker{
+		"a":   sleep(100 * time.Millisecond),
+		"b":   sleep(100 * time.Millisecond),
+		"c":   sleep(100 * time.Millisecond),
+		"bad": CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") }),
+	}, time.Second)
+
+	// checkers run in parallel
+	require.Less(t, time.Since(startTime), 250*time.Millisecond)
+	require.Equal(t, StatusFail, rep.Status)
+	require.Len(t, rep.Components, 4)
+	for _, name := range []string{"a", "b", "c"} {
+		require.Equal(t, StatusOk, rep.Components[name].Status)
+		require.Empty(t, rep.Components[name].Error)
+		require.GreaterOrEqual(t, rep.Components[name].DurationMs, int64(100))
+	}
+	require.Equal(t, StatusFail, rep.Components["bad"].Status)
+	require.Equal(t, "connection refused", rep.Components["bad"].Error)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	startTime := time.Now()
+
+	rep := Check(context.Background(), map[string]Checker{
+		"ctx_aware": CheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		"ignores_ctx": CheckerFunc(func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}),
+		"fast": CheckerFunc(func(ctx context.Context) error { return nil }),
+	}, 50*time.Millisecond)
+
+	require.Less(t, time.Since(startTime), 500*time.Millisecond)
+	require.Equal(t, StatusFail, rep.Status)
+	require.Equal(t, StatusFail, rep.Components["ctx_aware"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), rep.Components["ctx_aware"].Error)
+	require.Equal(t, StatusFail, rep.Components["ignores_ctx"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), rep.Components["ignores_ctx"].Error)
+	require.Equal(t, StatusOk, rep.Components["fast"].Status)
+}
@@ -0,0 +1,16 @@
+package health
+
+import (
+	"context"
+)
+
+// Checker is implemented by adapters, nil error means healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
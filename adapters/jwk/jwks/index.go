@@ -1,35 +1,59 @@
 package jwks
 
 import (
+	"context"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/MicahParks/keyfunc"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/supernova0730/dop/adapters/logger"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+const (
+	RefreshTimeout = 10 * time.Second
 )
 
 type St struct {
 	lg logger.WarnAndError
 
 	jwks *keyfunc.JWKS
+
+	maxRefreshAge time.Duration
+	lastRefresh   time.Time
+	lastErr       error
+	mu            sync.RWMutex
 }
 
 func NewByUrl(lg logger.WarnAndError, url string, refreshInterval time.Duration) (*St, error) {
+	p := &St{lg: lg}
+
+	// keys are considered stale if two refreshes in a row failed
+	if refreshInterval > 0 {
+		p.maxRefreshAge = 2*refreshInterval + RefreshTimeout
+	}
+
 	jwks, err := keyfunc.Get(url, keyfunc.Options{
+		Client:          &http.Client{Transport: &refreshTransportSt{p: p}},
 		RefreshInterval: refreshInterval,
-		RefreshTimeout:  10 * time.Second,
+		RefreshTimeout:  RefreshTimeout,
 		RefreshErrorHandler: func(err error) {
 			lg.Errorw("Jwks refresh error", err)
+
+			p.mu.Lock()
+			p.lastErr = err
+			p.mu.Unlock()
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &St{
-		lg:   lg,
-		jwks: jwks,
-	}, nil
+	p.jwks = jwks
+
+	return p, nil
 }
 
 func (p *St) Validate(token string) (bool, error) {
@@ -40,3 +64,60 @@ func (p *St) Validate(token string) (bool, error) {
 
 	return jwtToken.Valid, nil
 }
+
+// LastRefresh returns time of the last successful fetch of keys
+func (p *St) LastRefresh() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.lastRefresh
+}
+
+// Check fails if keys were not refreshed for too long, implements health.Checker
+func (p *St) Check(ctx context.Context) error {
+	if p.maxRefreshAge <= 0 {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	age := time.Since(p.lastRefresh)
+	if age <= p.maxRefreshAge {
+		return nil
+	}
+
+	desc := "keys are not refreshed for " + age.Truncate(time.Second).String()
+	if p.lastErr != nil {
+		desc += ": " + p.lastErr.Error()
+	}
+
+	return dopErrs.ServiceNA.WithDesc(desc)
+}
+
+// Close stops background refresh of keys
+func (p *St) Close() {
+	p.jwks.EndBackground()
+}
+
+func (p *St) setRefreshed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastRefresh = time.Now()
+	p.lastErr = nil
+}
+
+// refreshTransportSt tracks successful fetches of keys
+type refreshTransportSt struct {
+	p *St
+}
+
+func (t *refreshTransportSt) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		t.p.setRefreshed()
+	}
+
+	return resp, err
+}
@@ -1,5 +1,10 @@
 package jwt
 
+import (
+	"context"
+)
+
 type Jwt interface {
 	Create(sub string, expSeconds int64, payload map[string]any) (string, error)
+	CreateCtx(ctx context.Context, sub string, expSeconds int64, payload map[string]any) (string, error)
 }
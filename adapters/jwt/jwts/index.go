@@ -1,6 +1,8 @@
 package jwts
 
 import (
+	"context"
+
 	"github.com/supernova0730/dop/adapters/client/httpc"
 )
 
@@ -15,6 +17,10 @@ func New(httpc httpc.HttpC) *St {
 }
 
 func (p *St) Create(sub string, expSeconds int64, payload map[string]any) (string, error) {
+	return p.CreateCtx(context.Background(), sub, expSeconds, payload)
+}
+
+func (p *St) CreateCtx(ctx context.Context, sub string, expSeconds int64, payload map[string]any) (string, error) {
 	data := map[string]any{}
 
 	for k, v := range payload {
@@ -31,12 +37,12 @@ func (p *St) Create(sub string, expSeconds int64, payload map[string]any) (strin
 
 	repObj := jwtCreateRepSt{}
 
-	_, _, err := p.httpc.SendJsonRecvJson(data, &repObj, nil, httpc.OptionsSt{
+	_, _, err := p.httpc.SendJsonRecvJsonCtx(ctx, data, &repObj, nil, httpc.OptionsSt{
 		Method: "POST",
 		Path:   "jwt",
 	})
 	if err != nil {
-		return "", err
+		return "", httpc.WrapExternal(err)
 	}
 
 	return repObj.Token, nil
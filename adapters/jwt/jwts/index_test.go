@@ -0,0 +1,43 @@
+package jwts
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/client/httpc/mock"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/adapters/server/https"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+func TestCreate(t *testing.T) {
+	c := mock.New(zap.New("info", true))
+	c.SetMethodResponse("POST", "jwt", mock.ResponseSt{Obj: jwtCreateRepSt{Token: "t0ken"}})
+
+	p := New(c)
+
+	token, err := p.Create("42", 60, nil)
+	require.NoError(t, err)
+	require.Equal(t, "t0ken", token)
+
+	// 401 of jwt service is not responded to the caller as is
+	c.SetMethodResponse("POST", "jwt", mock.ResponseSt{StatusCode: http.StatusUnauthorized})
+
+	_, err = p.Create("42", 60, nil)
+	require.ErrorIs(t, err, dopErrs.NotAuthorized)
+
+	var extErr dopErrs.ExternalError
+	require.True(t, errors.As(err, &extErr) && extErr.External())
+
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	status, rep := https.GetErrRep(ginCtx, err)
+	require.Equal(t, http.StatusBadGateway, status)
+	require.Equal(t, dopErrs.BadStatusCode.Error(), rep.ErrorCode)
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 
@@ -20,6 +21,10 @@ func New(lg logger.WarnAndError, testing bool) *St {
 }
 
 func (p *St) Create(sub string, expSeconds int64, payload map[string]any) (string, error) {
+	return p.CreateCtx(context.Background(), sub, expSeconds, payload)
+}
+
+func (p *St) CreateCtx(ctx context.Context, sub string, expSeconds int64, payload map[string]any) (string, error) {
 	pld := make(map[string]any, len(payload)+1)
 
 	for k, v := range payload {
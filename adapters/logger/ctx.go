@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+)
+
+type ctxKey int
+
+const requestIdCtxKey ctxKey = iota
+
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdCtxKey, requestId)
+}
+
+// RequestIdFromContext returns empty string if ctx has no request id
+func RequestIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	v, _ := ctx.Value(requestIdCtxKey).(string)
+
+	return v
+}
+
+// LiteWithCtx returns logger, which adds "request_id" from ctx to every call.
+// lg is returned as is if ctx has no request id
+func LiteWithCtx(lg Lite, ctx context.Context) Lite {
+	requestId := RequestIdFromContext(ctx)
+	if requestId == "" {
+		return lg
+	}
+
+	return &ctxLiteSt{lg: lg, args: []any{"request_id", requestId}}
+}
+
+// WarnAndErrorWithCtx is LiteWithCtx for WarnAndError
+func WarnAndErrorWithCtx(lg WarnAndError, ctx context.Context) WarnAndError {
+	requestId := RequestIdFromContext(ctx)
+	if requestId == "" {
+		return lg
+	}
+
+	return &ctxWarnAndErrorSt{lg: lg, args: []any{"request_id", requestId}}
+}
+
+type ctxWarnAndErrorSt struct {
+	lg   WarnAndError
+	args []any
+}
+
+func (l *ctxWarnAndErrorSt) Warnw(msg string, args ...any) {
+	l.lg.Warnw(msg, append(args, l.args...)...)
+}
+
+func (l *ctxWarnAndErrorSt) Errorw(msg string, err any, args ...any) {
+	l.lg.Errorw(msg, err, append(args, l.args...)...)
+}
+
+type ctxLiteSt struct {
+	lg   Lite
+	args []any
+}
+
+func (l *ctxLiteSt) Infow(msg string, args ...any) {
+	l.lg.Infow(msg, append(args, l.args...)...)
+}
+
+func (l *ctxLiteSt) Warnw(msg string, args ...any) {
+	l.lg.Warnw(msg, append(args, l.args...)...)
+}
+
+func (l *ctxLiteSt) Errorw(msg string, err any, args ...any) {
+	l.lg.Errorw(msg, err, append(args, l.args...)...)
+}
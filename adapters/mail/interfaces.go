@@ -1,5 +1,10 @@
 package mail
 
+import (
+	"context"
+)
+
 type Mail interface {
 	Send(data *SendReqSt) bool
+	SendCtx(ctx context.Context, data *SendReqSt) bool
 }
@@ -1,6 +1,8 @@
 package mails
 
 import (
+	"context"
+
 	"github.com/supernova0730/dop/adapters/client/httpc"
 	"github.com/supernova0730/dop/adapters/mail"
 )
@@ -16,7 +18,11 @@ func New(httpc httpc.HttpC) *St {
 }
 
 func (m *St) Send(data *mail.SendReqSt) bool {
-	_, _, err := m.httpc.SendJson(data, httpc.OptionsSt{
+	return m.SendCtx(context.Background(), data)
+}
+
+func (m *St) SendCtx(ctx context.Context, data *mail.SendReqSt) bool {
+	_, _, err := m.httpc.SendJsonCtx(ctx, data, httpc.OptionsSt{
 		Method: "POST",
 		Path:   "send",
 	})
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"sync"
 
 	"github.com/supernova0730/dop/adapters/logger"
@@ -26,6 +27,10 @@ func New(
 }
 
 func (m *St) Send(data *mail.SendReqSt) bool {
+	return m.SendCtx(context.Background(), data)
+}
+
+func (m *St) SendCtx(ctx context.Context, data *mail.SendReqSt) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -0,0 +1,95 @@
+package https
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/supernova0730/dop/adapters/jwk"
+	"github.com/supernova0730/dop/adapters/jwt"
+	"github.com/supernova0730/dop/dopErrs"
+)
+
+const (
+	authClaimsCtxKey = "dop_auth_claims"
+	authUserIdCtxKey = "dop_auth_user_id"
+)
+
+type AuthOptionsSt[T any] struct {
+	// Optional lets requests without token pass, invalid token is rejected anyway
+	Optional bool
+	// Check is optional additional check of claims, e.g. roles, the returned error aborts request
+	Check func(c *gin.Context, claims *T) error
+}
+
+type authSubSt struct {
+	Sub string `json:"sub"`
+}
+
+// MwAuth validates token (see GetAuthToken) with jwkSrv, parses its claims into T and stores them in context,
+// see GetAuthClaims, GetAuthUserId. Request is aborted with dopErrs.NotAuthorized, which is rendered by MwRecovery,
+// without MwRecovery only status code is responded
+func MwAuth[T any](jwkSrv jwk.Jwk, opts AuthOptionsSt[T]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := GetAuthToken(c)
+		if token == "" {
+			if !opts.Optional {
+				abortWithErr(c, dopErrs.NotAuthorized)
+			}
+			return
+		}
+
+		valid, err := jwkSrv.Validate(token)
+		if err != nil || !valid {
+			abortWithErr(c, dopErrs.NotAuthorized)
+			return
+		}
+
+		claims := new(T)
+		sub := authSubSt{}
+
+		if jwt.ParsePayload(token, claims) != nil || jwt.ParsePayload(token, &sub) != nil {
+			abortWithErr(c, dopErrs.NotAuthorized)
+			return
+		}
+
+		if opts.Check != nil {
+			if err = opts.Check(c, claims); err != nil {
+				abortWithErr(c, err)
+				return
+			}
+		}
+
+		c.Set(authClaimsCtxKey, claims)
+		c.Set(authUserIdCtxKey, sub.Sub)
+	}
+}
+
+// GetAuthClaims returns claims stored by MwAuth with the same T, false for not authorized request
+func GetAuthClaims[T any](c *gin.Context) (*T, bool) {
+	v, ok := c.Get(authClaimsCtxKey)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := v.(*T)
+
+	return claims, ok
+}
+
+// GetAuthUserId returns "sub" claim of token, empty for not authorized request
+func GetAuthUserId(c *gin.Context) string {
+	return c.GetString(authUserIdCtxKey)
+}
+
+// IsAuthorized is useful for routes with optional auth
+func IsAuthorized(c *gin.Context) bool {
+	_, ok := c.Get(authClaimsCtxKey)
+	return ok
+}
+
+// abortWithErr records err for MwRecovery and sets its status code.
+// Headers are not written here, so MwRecovery can still set them, e.g. problem content type
+func abortWithErr(c *gin.Context, err error) {
+	Error(c, err)
+	status, _ := GetErrRep(c, err)
+	c.Status(status)
+	c.Abort()
+}
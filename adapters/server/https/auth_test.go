@@ -0,0 +1,128 @@
+package https
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopErrs"
+	"github.com/supernova0730/dop/dopTypes"
+)
+
+// testJwkSt accepts tokens with signature "sig"
+type testJwkSt struct{}
+
+func (testJwkSt) Validate(token string) (bool, error) {
+	return strings.HasSuffix(token, ".sig"), nil
+}
+
+type testClaimsSt struct {
+	Sub   string   `json:"sub"`
+	Roles []string `json:"roles"`
+}
+
+func newTestToken(claims any, sig string) string {
+	raw, _ := json.Marshal(claims)
+	return "XXX." + base64.RawURLEncoding.EncodeToString(raw) + "." + sig
+}
+
+func TestMwAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(MwRecovery(zap.New("info", true), nil))
+
+	handler := func(c *gin.Context) {
+		claims, ok := GetAuthClaims[testClaimsSt](c)
+		if !ok {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		require.True(t, IsAuthorized(c))
+		c.String(http.StatusOK, GetAuthUserId(c)+":"+strings.Join(claims.Roles, ","))
+	}
+
+	r.GET("/private", MwAuth(testJwkSt{}, AuthOptionsSt[testClaimsSt]{}), handler)
+	r.GET("/optional", MwAuth(testJwkSt{}, AuthOptionsSt[testClaimsSt]{Optional: true}), handler)
+	r.GET("/admin", MwAuth(testJwkSt{}, AuthOptionsSt[testClaimsSt]{
+		Check: func(c *gin.Context, claims *testClaimsSt) error {
+			for _, role := range claims.Roles {
+				if role == "admin" {
+					return nil
+				}
+			}
+			return dopErrs.PermissionDenied
+		},
+	}), handler)
+
+	userToken := newTestToken(testClaimsSt{Sub: "42", Roles: []string{"user"}}, "sig")
+
+	for _, tt := range []struct {
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+		wantErr    dopErrs.Err
+	}{
+		{path: "/private", token: userToken, wantStatus: http.StatusOK, wantBody: "42:user"},
+		{path: "/private", wantStatus: http.StatusUnauthorized, wantErr: dopErrs.NotAuthorized},
+		{path: "/private", token: newTestToken(testClaimsSt{Sub: "42"}, "bad"), wantStatus: http.StatusUnauthorized, wantErr: dopErrs.NotAuthorized},
+		{path: "/private", token: "XXX.!!!.sig", wantStatus: http.StatusUnauthorized, wantErr: dopErrs.NotAuthorized},
+		{path: "/optional", wantStatus: http.StatusOK, wantBody: "anonymous"},
+		{path: "/optional", token: userToken, wantStatus: http.StatusOK, wantBody: "42:user"},
+		{path: "/optional", token: newTestToken(testClaimsSt{Sub: "42"}, "bad"), wantStatus: http.StatusUnauthorized, wantErr: dopErrs.NotAuthorized},
+		{path: "/admin", token: userToken, wantStatus: http.StatusForbidden, wantErr: dopErrs.PermissionDenied},
+		{path: "/admin", token: newTestToken(testClaimsSt{Sub: "1", Roles: []string{"admin"}}, "sig"), wantStatus: http.StatusOK, wantBody: "1:admin"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, tt.wantStatus, w.Code, tt.path)
+
+		if tt.wantErr != "" {
+			rep := dopTypes.ErrRep{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep), tt.path)
+			require.Equal(t, tt.wantErr.Error(), rep.ErrorCode, tt.path)
+		} else {
+			require.Equal(t, tt.wantBody, w.Body.String(), tt.path)
+		}
+	}
+}
+
+func TestMwAuthWithoutRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/private", MwAuth(testJwkSt{}, AuthOptionsSt[testClaimsSt]{}), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Empty(t, w.Body.String())
+
+	// MwRecovery still sets its headers
+	r = gin.New()
+	r.Use(MwRecoveryWithOptions(zap.New("info", true), RecoveryOptionsSt{Problem: true}))
+	r.GET("/private", MwAuth(testJwkSt{}, AuthOptionsSt[testClaimsSt]{}), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,192 @@
+package https
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cors "github.com/rs/cors/wrapper/gin"
+)
+
+var (
+	CorsDefaultMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+	}
+	CorsDefaultHeaders = []string{
+		"Origin",
+		"Accept",
+		"Accept-Language",
+		"Content-Type",
+		"Authorization",
+		"X-Requested-With",
+		RequestIdHeader,
+	}
+)
+
+type CorsOptionsSt struct {
+	// AllowedOrigins are exact origins ("https://example.com") or origins with wildcard subdomain
+	// ("https://*.example.com", matches any depth, not the domain itself). "*" requires Dev
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// AllowedOriginPatterns are regular expressions, matched against the whole lowercased origin
+	AllowedOriginPatterns []string      `mapstructure:"allowed_origin_patterns"`
+	AllowedMethods        []string      `mapstructure:"allowed_methods"` // default CorsDefaultMethods
+	AllowedHeaders        []string      `mapstructure:"allowed_headers"` // default CorsDefaultHeaders
+	ExposedHeaders        []string      `mapstructure:"exposed_headers"`
+	MaxAge                time.Duration `mapstructure:"max_age"` // preflight cache, 0 - not set
+	AllowCredentials      bool          `mapstructure:"allow_credentials"`
+	// Dev allows any origin together with credentials, never use it in production
+	Dev bool `mapstructure:"dev"`
+}
+
+// CorsDevOptions allows everything: any origin and header, all methods, credentials
+func CorsDevOptions() CorsOptionsSt {
+	return CorsOptionsSt{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+			http.MethodConnect,
+			http.MethodOptions,
+			http.MethodTrace,
+		},
+		AllowedHeaders:   []string{"*"},
+		MaxAge:           7 * 24 * time.Hour,
+		AllowCredentials: true,
+		Dev:              true,
+	}
+}
+
+func (o *CorsOptionsSt) mergeWithDefaults() {
+	if len(o.AllowedMethods) == 0 {
+		o.AllowedMethods = CorsDefaultMethods
+	}
+	if len(o.AllowedHeaders) == 0 {
+		o.AllowedHeaders = CorsDefaultHeaders
+	}
+}
+
+// MwCors returns error for invalid origin pattern or "*" origin with credentials in non-dev mode
+func MwCors(opts CorsOptionsSt) (gin.HandlerFunc, error) {
+	opts.mergeWithDefaults()
+
+	matcher, err := newOriginMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return cors.New(cors.Options{
+		AllowOriginFunc:  matcher.match,
+		AllowedMethods:   opts.AllowedMethods,
+		AllowedHeaders:   opts.AllowedHeaders,
+		ExposedHeaders:   opts.ExposedHeaders,
+		MaxAge:           int(opts.MaxAge / time.Second),
+		AllowCredentials: opts.AllowCredentials,
+	}), nil
+}
+
+type wildcardOriginSt struct {
+	prefix string // "https://"
+	suffix string // ".example.com"
+}
+
+type originMatcherSt struct {
+	any       bool
+	exact     map[string]bool
+	wildcards []wildcardOriginSt
+	patterns  []*regexp.Regexp
+}
+
+func newOriginMatcher(opts CorsOptionsSt) (*originMatcherSt, error) {
+	result := &originMatcherSt{
+		exact: make(map[string]bool, len(opts.AllowedOrigins)),
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+
+		switch i := strings.Index(origin, "://*."); {
+		case origin == "*":
+			if opts.AllowCredentials && !opts.Dev {
+				return nil, errors.New("cors: origin \"*\" with credentials is allowed only in dev mode")
+			}
+			result.any = true
+		case i >= 0:
+			result.wildcards = append(result.wildcards, wildcardOriginSt{
+				prefix: origin[:i+3],
+				suffix: origin[i+4:],
+			})
+		case strings.Contains(origin, "*"):
+			return nil, errors.New("cors: bad origin " + origin + ", wildcard is allowed only for subdomain")
+		default:
+			result.exact[origin] = true
+		}
+	}
+
+	for _, pattern := range opts.AllowedOriginPatterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, errors.New("cors: bad origin pattern " + pattern + ": " + err.Error())
+		}
+		result.patterns = append(result.patterns, re)
+	}
+
+	return result, nil
+}
+
+func (m *originMatcherSt) match(origin string) bool {
+	if m.any {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+
+	if m.exact[origin] {
+		return true
+	}
+
+	for _, w := range m.wildcards {
+		if len(origin) > len(w.prefix)+len(w.suffix) &&
+			strings.HasPrefix(origin, w.prefix) &&
+			strings.HasSuffix(origin, w.suffix) &&
+			isHostLabels(origin[len(w.prefix):len(origin)-len(w.suffix)]) {
+			return true
+		}
+	}
+
+	for _, re := range m.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHostLabels checks subdomain part of origin, e.g. "api" or "a.b", ports and credentials are not allowed
+func isHostLabels(v string) bool {
+	for _, label := range strings.Split(v, ".") {
+		if label == "" {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			ch := label[i]
+			if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
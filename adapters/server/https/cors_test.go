@@ -0,0 +1,119 @@
+package https
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMwCors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mw, err := MwCors(CorsOptionsSt{
+		AllowedOrigins:        []string{"https://example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []string{`http://localhost:\d+`},
+		ExposedHeaders:        []string{RequestIdHeader},
+		MaxAge:                time.Hour,
+		AllowCredentials:      true,
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(mw)
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, tt := range []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://example.com", true},
+		{"https://EXAMPLE.com", true},
+		{"http://example.com", false},
+		{"https://api.example.com", false},
+		{"https://api.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil.com/.example.org", false},
+		{"https://evil.com:1@x.example.org", false},
+		{"https://evilexample.org", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3000.evil.com", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if tt.allowed {
+			require.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"), tt.origin)
+			require.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"), tt.origin)
+			require.Equal(t, http.CanonicalHeaderKey(RequestIdHeader), w.Header().Get("Access-Control-Expose-Headers"), tt.origin)
+		} else {
+			require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), tt.origin)
+		}
+	}
+
+	// preflight
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, http.MethodPut, w.Header().Get("Access-Control-Allow-Methods"))
+	require.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+
+	// method is not allowed by default
+	req.Header.Set("Access-Control-Request-Method", http.MethodTrace)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestMwCorsOptions(t *testing.T) {
+	_, err := MwCors(CorsOptionsSt{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	require.Error(t, err)
+
+	_, err = MwCors(CorsOptionsSt{AllowedOrigins: []string{"*"}})
+	require.NoError(t, err)
+
+	_, err = MwCors(CorsDevOptions())
+	require.NoError(t, err)
+
+	_, err = MwCors(CorsOptionsSt{AllowedOrigins: []string{"https://api.*.com"}})
+	require.Error(t, err)
+
+	_, err = MwCors(CorsOptionsSt{AllowedOriginPatterns: []string{"("}})
+	require.Error(t, err)
+
+	// from config
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+cors:
+  allowed_origins: ["https://example.com", "https://*.example.com"]
+  allowed_methods: [GET, POST]
+  exposed_headers: [X-Request-ID]
+  max_age: 10m
+  allow_credentials: true
+`)))
+
+	var opts CorsOptionsSt
+	require.NoError(t, v.UnmarshalKey("cors", &opts))
+	require.Equal(t, CorsOptionsSt{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		MaxAge:           10 * time.Minute,
+		AllowCredentials: true,
+	}, opts)
+}
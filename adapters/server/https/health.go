@@ -0,0 +1,53 @@
+package https
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/supernova0730/dop/adapters/health"
+	"github.com/supernova0730/dop/adapters/logger"
+)
+
+type HealthOptionsSt struct {
+	Liveness  map[string]health.Checker // /healthz, usually empty: process is alive if it responds
+	Readiness map[string]health.Checker // /readyz, e.g. db, cache, external services
+	Timeout   time.Duration             // per checker, default health.DefaultTimeout
+	// ShowErrors adds error messages to response, they may contain hosts, users etc.,
+	// by default errors are only logged
+	ShowErrors bool
+}
+
+// RegisterHealthRoutes registers GET /healthz and /readyz
+func RegisterHealthRoutes(r gin.IRoutes, lg logger.WarnAndError, opts HealthOptionsSt) {
+	r.GET("/healthz", HealthHandler(lg, opts.Liveness, opts))
+	r.GET("/readyz", HealthHandler(lg, opts.Readiness, opts))
+}
+
+// HealthHandler runs checkers in parallel and responds with per-component status,
+// status code is 503 if any checker fails
+func HealthHandler(lg logger.WarnAndError, checkers map[string]health.Checker, opts HealthOptionsSt) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rep := health.Check(c.Request.Context(), checkers, opts.Timeout)
+
+		statusCode := http.StatusOK
+		if rep.Status != health.StatusOk {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		for name, component := range rep.Components {
+			if component.Error == "" {
+				continue
+			}
+
+			lg.Errorw("Health check failed", component.Error, "component", name, "path", c.Request.URL.Path)
+
+			if !opts.ShowErrors {
+				component.Error = ""
+			}
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(statusCode, rep)
+	}
+}
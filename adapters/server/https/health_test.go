@@ -0,0 +1,66 @@
+package https
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/health"
+)
+
+func TestHealthRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	readiness := map[string]health.Checker{
+		"db": health.CheckerFunc(func(ctx context.Context) error {
+			return nil
+		}),
+		"cache": health.CheckerFunc(func(ctx context.Context) error {
+			return errors.New("dial tcp 10.0.0.1:6379: connection refused")
+		}),
+	}
+
+	do := func(r *gin.Engine, path string) (int, *health.RepSt) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		rep := &health.RepSt{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), rep))
+
+		return w.Code, rep
+	}
+
+	lg := &testLoggerSt{}
+
+	r := gin.New()
+	RegisterHealthRoutes(r, lg, HealthOptionsSt{Readiness: readiness})
+
+	statusCode, rep := do(r, "/healthz")
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, health.StatusOk, rep.Status)
+
+	statusCode, rep = do(r, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, statusCode)
+	require.Equal(t, health.StatusFail, rep.Status)
+	require.Len(t, rep.Components, 2)
+	require.Equal(t, health.StatusOk, rep.Components["db"].Status)
+	require.Equal(t, health.StatusFail, rep.Components["cache"].Status)
+	require.Empty(t, rep.Components["cache"].Error)
+
+	// error is logged
+	require.Len(t, lg.records, 1)
+	require.Equal(t, "error", lg.records[0].level)
+	require.Equal(t, "cache", lg.records[0].args["component"])
+
+	// errors in response
+	r = gin.New()
+	RegisterHealthRoutes(r, lg, HealthOptionsSt{Readiness: readiness, ShowErrors: true})
+
+	_, rep = do(r, "/readyz")
+	require.Equal(t, "dial tcp 10.0.0.1:6379: connection refused", rep.Components["cache"].Error)
+}
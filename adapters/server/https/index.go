@@ -2,17 +2,20 @@ package https
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	cors "github.com/rs/cors/wrapper/gin"
 	"github.com/supernova0730/dop/adapters/logger"
 	"github.com/supernova0730/dop/dopErrs"
 	"github.com/supernova0730/dop/dopTypes"
+	"golang.org/x/text/language"
 )
 
 const (
@@ -21,39 +24,165 @@ const (
 	MaxHeaderBytes    = 300 * 1024
 )
 
+const langCtxKey = "dop_lang"
+
 type St struct {
 	lg logger.Lite
 
-	addr   string
-	server *http.Server
-	eChan  chan error
+	addr     string
+	server   *http.Server
+	listener net.Listener
+	reloader *certReloaderSt
+	eChan    chan error
+}
+
+type OptionsSt struct {
+	Addr       string       // tcp address
+	UnixSocket string       // socket file path, used instead of Addr
+	Listener   net.Listener // pre-opened listener, used instead of Addr and UnixSocket
+
+	ReadHeaderTimeout time.Duration // default ReadHeaderTimeout
+	ReadTimeout       time.Duration // default ReadTimeout
+	WriteTimeout      time.Duration // 0 - no timeout
+	IdleTimeout       time.Duration // 0 - ReadTimeout is used
+	MaxHeaderBytes    int           // default MaxHeaderBytes
+
+	TLS *TLSOptionsSt
+}
+
+type TLSOptionsSt struct {
+	CertFile       string
+	KeyFile        string
+	ReloadInterval time.Duration // how often files are checked for changes, 0 - no reload
+	MinVersion     uint16        // default tls.VersionTLS12
+	// ClientCAFile enables client certificates verification (mTLS)
+	ClientCAFile string
+	ClientAuth   tls.ClientAuthType // default tls.RequireAndVerifyClientCert if ClientCAFile is set
+}
+
+func (o *OptionsSt) mergeWithDefaults() {
+	if o.ReadHeaderTimeout == 0 {
+		o.ReadHeaderTimeout = ReadHeaderTimeout
+	}
+	if o.ReadTimeout == 0 {
+		o.ReadTimeout = ReadTimeout
+	}
+	if o.MaxHeaderBytes == 0 {
+		o.MaxHeaderBytes = MaxHeaderBytes
+	}
 }
 
 func Start(addr string, handler http.Handler, lg logger.Lite) *St {
+	s, err := StartWithOptions(handler, lg, OptionsSt{Addr: addr})
+	if err != nil {
+		s = &St{
+			lg:     lg,
+			addr:   addr,
+			server: &http.Server{Addr: addr},
+			eChan:  make(chan error, 1),
+		}
+		s.eChan <- err
+	}
+
+	return s
+}
+
+// StartWithOptions listens synchronously and serves in background, serve errors are sent to Wait channel
+func StartWithOptions(handler http.Handler, lg logger.Lite, opts OptionsSt) (*St, error) {
+	opts.mergeWithDefaults()
+
 	s := &St{
 		lg:   lg,
-		addr: addr,
+		addr: opts.Addr,
 		server: &http.Server{
-			Addr:              addr,
+			Addr:              opts.Addr,
 			Handler:           handler,
-			ReadHeaderTimeout: ReadHeaderTimeout,
-			ReadTimeout:       ReadTimeout,
-			MaxHeaderBytes:    MaxHeaderBytes,
+			ReadHeaderTimeout: opts.ReadHeaderTimeout,
+			ReadTimeout:       opts.ReadTimeout,
+			WriteTimeout:      opts.WriteTimeout,
+			IdleTimeout:       opts.IdleTimeout,
+			MaxHeaderBytes:    opts.MaxHeaderBytes,
 		},
 		eChan: make(chan error, 1),
 	}
 
-	s.lg.Infow("Start rest-api", "addr", s.server.Addr)
+	if opts.TLS != nil {
+		tlsConfig, err := s.newTLSConfig(opts.TLS)
+		if err != nil {
+			s.lg.Errorw("Fail to configure tls", err)
+			return nil, err
+		}
+		s.server.TLSConfig = tlsConfig
+	}
+
+	var err error
+
+	switch {
+	case opts.Listener != nil:
+		s.listener = opts.Listener
+	case opts.UnixSocket != "":
+		if err = removeStaleSocket(opts.UnixSocket); err != nil {
+			s.lg.Errorw("Fail to remove unix socket", err, "path", opts.UnixSocket)
+			s.stopReloader()
+			return nil, err
+		}
+		s.listener, err = net.Listen("unix", opts.UnixSocket)
+	default:
+		addr := opts.Addr
+		if addr == "" {
+			addr = ":http"
+			if opts.TLS != nil {
+				addr = ":https"
+			}
+		}
+		s.listener, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		s.lg.Errorw("Fail to listen", err, "addr", opts.Addr, "unix_socket", opts.UnixSocket)
+		s.stopReloader()
+		return nil, err
+	}
+
+	s.addr = s.listener.Addr().String()
+
+	s.lg.Infow("Start rest-api", "addr", s.addr, "tls", opts.TLS != nil)
 
 	go func() {
-		err := s.server.ListenAndServe()
+		var err error
+		if s.server.TLSConfig != nil {
+			err = s.server.ServeTLS(s.listener, "", "")
+		} else {
+			err = s.server.Serve(s.listener)
+		}
 		if err != nil && err != http.ErrServerClosed {
 			s.lg.Errorw("Http server closed", err)
 			s.eChan <- err
 		}
 	}()
 
-	return s
+	return s, nil
+}
+
+// removeStaleSocket removes socket file left by previous process, other files are not touched
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return errors.New("not a unix socket: " + path)
+	}
+
+	return os.Remove(path)
+}
+
+// Addr returns actual listening address
+func (s *St) Addr() string {
+	return s.addr
 }
 
 func (s *St) Wait() <-chan error {
@@ -61,18 +190,24 @@ func (s *St) Wait() <-chan error {
 }
 
 func (s *St) Shutdown(timeout time.Duration) bool {
-	defer close(s.eChan)
-
 	ctx, ctxCancel := context.WithTimeout(context.Background(), timeout)
 	defer ctxCancel()
 
+	return s.ShutdownCtx(ctx) == nil
+}
+
+// ShutdownCtx waits for active requests until ctx is done
+func (s *St) ShutdownCtx(ctx context.Context) error {
+	defer close(s.eChan)
+	defer s.stopReloader()
+
 	err := s.server.Shutdown(ctx)
 	if err != nil {
 		s.lg.Errorw("Fail to shutdown http-api", err, "addr", s.addr)
-		return false
+		return err
 	}
 
-	return true
+	return nil
 }
 
 func Error(c *gin.Context, err error) bool {
@@ -97,9 +232,29 @@ func GetAuthToken(c *gin.Context) string {
 	return token
 }
 
+// GetLang returns supported language negotiated from Accept-Language header, see dopErrs.MatchLang
+func GetLang(c *gin.Context) language.Tag {
+	if v, ok := c.Get(langCtxKey); ok {
+		if lang, ok := v.(language.Tag); ok {
+			return lang
+		}
+	}
+
+	lang := dopErrs.MatchLang(c.GetHeader("Accept-Language"))
+
+	c.Set(langCtxKey, lang)
+
+	return lang
+}
+
 func BindJSON(c *gin.Context, obj any) bool {
 	err := c.ShouldBindJSON(obj)
 	if err != nil {
+		if formErr, ok := ValidationFormErr(err, obj, "json"); ok {
+			Error(c, formErr)
+			return false
+		}
+
 		Error(c, dopErrs.ErrWithDesc{
 			Err:  dopErrs.BadJson,
 			Desc: err.Error(),
@@ -114,6 +269,11 @@ func BindJSON(c *gin.Context, obj any) bool {
 func BindQuery(c *gin.Context, obj any) bool {
 	err := c.ShouldBindQuery(obj)
 	if err != nil {
+		if formErr, ok := ValidationFormErr(err, obj, "form"); ok {
+			Error(c, formErr)
+			return false
+		}
+
 		Error(c, dopErrs.ErrWithDesc{
 			Err:  dopErrs.BadQueryParams,
 			Desc: err.Error(),
@@ -125,7 +285,18 @@ func BindQuery(c *gin.Context, obj any) bool {
 	return true
 }
 
+type RecoveryOptionsSt struct {
+	Handler func(*gin.Context, error) // replaces default error response
+	Problem bool                      // respond with application/problem+json (RFC 7807) instead of dopTypes.ErrRep
+	// ProblemTypeBase is prefix of problem type uri, the error code is appended, "about:blank" is used if empty
+	ProblemTypeBase string
+}
+
 func MwRecovery(lg logger.WarnAndError, handler func(*gin.Context, error)) gin.HandlerFunc {
+	return MwRecoveryWithOptions(lg, RecoveryOptionsSt{Handler: handler})
+}
+
+func MwRecoveryWithOptions(lg logger.WarnAndError, opts RecoveryOptionsSt) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			var err error
@@ -134,7 +305,7 @@ func MwRecovery(lg logger.WarnAndError, handler func(*gin.Context, error)) gin.H
 				if gErr.IsType(gin.ErrorTypeBind) {
 					err = dopErrs.ErrWithDesc{
 						Err:  dopErrs.BadJson,
-						Desc: err.Error(),
+						Desc: gErr.Err.Error(),
 					}
 				} else {
 					err = gErr.Err
@@ -150,64 +321,119 @@ func MwRecovery(lg logger.WarnAndError, handler func(*gin.Context, error)) gin.H
 				return
 			}
 
-			if handler != nil {
-				handler(c, err)
+			if opts.Handler != nil {
+				opts.Handler(c, err)
 				return
 			}
 
-			switch cErr := err.(type) {
-			case dopErrs.Err:
-				c.AbortWithStatusJSON(http.StatusBadRequest, dopTypes.ErrRep{
-					ErrorCode: cErr.Error(),
-				})
-			case dopErrs.ErrWithDesc:
-				c.AbortWithStatusJSON(http.StatusBadRequest, dopTypes.ErrRep{
-					ErrorCode: cErr.Err.Error(),
-					Desc:      cErr.Desc,
-				})
-			case dopErrs.FormErr:
-				fields := map[string]string{}
-
-				for k, v := range cErr.Fields {
-					fields[k] = v.Error()
-				}
-
-				c.AbortWithStatusJSON(http.StatusBadRequest, dopTypes.ErrRep{
-					ErrorCode: dopErrs.FormValidate.Error(),
-					Fields:    fields,
-				})
-			default:
-				lg.Errorw(
+			status, rep := GetErrRep(c, err)
+			if rep == nil {
+				logger.WarnAndErrorWithCtx(lg, c.Request.Context()).Errorw(
 					"Error in httpc handler",
 					err,
 					"method", c.Request.Method,
 					"path", c.Request.URL.String(),
 				)
+			}
+
+			if opts.Problem {
+				c.Header("Content-Type", "application/problem+json")
+				problem := NewProblemRep(status, rep, opts.ProblemTypeBase)
+				problem.Instance = c.Request.URL.Path
+				c.AbortWithStatusJSON(status, problem)
+				return
+			}
 
-				c.AbortWithStatus(http.StatusInternalServerError)
+			if rep == nil {
+				c.AbortWithStatus(status)
+				return
 			}
+
+			c.AbortWithStatusJSON(status, rep)
 		}()
 
 		c.Next()
 	}
 }
 
-func MwCors() gin.HandlerFunc {
-	return cors.New(cors.Options{
-		AllowOriginFunc: func(origin string) bool { return true },
-		AllowedMethods: []string{
-			http.MethodGet,
-			http.MethodHead,
-			http.MethodPost,
-			http.MethodPut,
-			http.MethodPatch,
-			http.MethodDelete,
-			http.MethodConnect,
-			http.MethodOptions,
-			http.MethodTrace,
-		},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           604800,
-	})
+// GetErrRep returns http status and response for dopErrs errors, rep is nil for other (internal) errors.
+// Client errors (4xx) of external services (dopErrs.ExternalError) are responded as dopErrs.BadStatusCode,
+// e.g. 401 of upstream service must not log out the caller. Errors of httpc Send* methods are not marked,
+// adapters wrap them with httpc.WrapExternal
+func GetErrRep(c *gin.Context, err error) (int, *dopTypes.ErrRep) {
+	var extErr dopErrs.ExternalError
+
+	if errors.As(err, &extErr) && extErr.External() {
+		status, rep := GetErrRep(c, errors.Unwrap(extErr))
+		if rep != nil && status < 500 {
+			return GetErrRep(c, dopErrs.BadStatusCode)
+		}
+		return status, rep
+	}
+
+	var formErr dopErrs.FormErr
+	var descErr dopErrs.ErrWithDesc
+	var dErr dopErrs.Err
+
+	// FormErr and ErrWithDesc unwrap to Err, so they are checked first
+	switch {
+	case errors.As(err, &formErr):
+		lang := GetLang(c)
+		fields := map[string]string{}
+		fieldMessages := map[string]string{}
+
+		for k, v := range formErr.Fields {
+			fields[k] = v.Error()
+			if msg := dopErrs.Message(v, lang); msg != "" {
+				fieldMessages[k] = msg
+			}
+		}
+
+		return formErr.HttpStatus(), &dopTypes.ErrRep{
+			ErrorCode:     dopErrs.FormValidate.Error(),
+			Fields:        fields,
+			Message:       dopErrs.Message(dopErrs.FormValidate, lang),
+			FieldMessages: fieldMessages,
+		}
+	case errors.As(err, &descErr):
+		return descErr.HttpStatus(), &dopTypes.ErrRep{
+			ErrorCode: descErr.Err.Error(),
+			Desc:      descErr.Desc,
+			Message:   dopErrs.Message(descErr.Err, GetLang(c)),
+		}
+	case errors.As(err, &dErr):
+		return dErr.HttpStatus(), &dopTypes.ErrRep{
+			ErrorCode: dErr.Error(),
+			Message:   dopErrs.Message(dErr, GetLang(c)),
+		}
+	default:
+		return http.StatusInternalServerError, nil
+	}
+}
+
+// NewProblemRep makes RFC 7807 problem from ErrRep, rep may be nil for internal errors
+func NewProblemRep(status int, rep *dopTypes.ErrRep, typeBase string) *dopTypes.ProblemRep {
+	result := &dopTypes.ProblemRep{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+
+	if rep == nil {
+		return result
+	}
+
+	if typeBase != "" {
+		result.Type = typeBase + rep.ErrorCode
+	}
+	if rep.Message != "" {
+		result.Title = rep.Message
+	}
+
+	result.Detail = rep.Desc
+	result.ErrorCode = rep.ErrorCode
+	result.Fields = rep.Fields
+	result.FieldMessages = rep.FieldMessages
+
+	return result
 }
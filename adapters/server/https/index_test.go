@@ -0,0 +1,196 @@
+package https
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/client/httpc"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopErrs"
+	"github.com/supernova0730/dop/dopTypes"
+	"golang.org/x/text/language"
+)
+
+func doRecovery(t *testing.T, handler gin.HandlerFunc, acceptLanguage string) (int, dopTypes.ErrRep) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(MwRecovery(zap.New("info", true), nil))
+	r.GET("/", handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", acceptLanguage)
+	r.ServeHTTP(w, req)
+
+	rep := dopTypes.ErrRep{}
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep))
+	}
+
+	return w.Code, rep
+}
+
+func TestMwRecovery(t *testing.T) {
+	dopErrs.RegisterMessages(language.English, map[dopErrs.Err]string{"bad_phone": "Invalid phone number"})
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		lang    string
+		status  int
+		rep     dopTypes.ErrRep
+	}{
+		{
+			"err",
+			func(c *gin.Context) { Error(c, dopErrs.ObjectNotFound) },
+			"en-US,en;q=0.9",
+			http.StatusNotFound,
+			dopTypes.ErrRep{ErrorCode: "object_not_found", Message: "Object not found"},
+		},
+		{
+			"err_with_desc",
+			func(c *gin.Context) { Error(c, dopErrs.ErrWithDesc{Err: dopErrs.NotAuthorized, Desc: "expired"}) },
+			"kk",
+			http.StatusUnauthorized,
+			dopTypes.ErrRep{ErrorCode: "not_authorized", Desc: "expired", Message: "Авторизация қажет"},
+		},
+		{
+			"form_err",
+			func(c *gin.Context) {
+				Error(c, dopErrs.FormErr{Fields: map[string]dopErrs.Err{"phone": dopErrs.Err("bad_phone")}})
+			},
+			"de, en;q=0.5",
+			http.StatusBadRequest,
+			dopTypes.ErrRep{
+				ErrorCode:     "form_validate",
+				Fields:        map[string]string{"phone": "bad_phone"},
+				Message:       "Check the entered data",
+				FieldMessages: map[string]string{"phone": "Invalid phone number"},
+			},
+		},
+		{
+			"wrapped",
+			func(c *gin.Context) {
+				Error(c, fmt.Errorf("get user: %w", dopErrs.PermissionDenied.WithDesc("not owner").WithCause(errors.New("db"))))
+			},
+			"",
+			http.StatusForbidden,
+			dopTypes.ErrRep{ErrorCode: "permission_denied", Desc: "not owner", Message: "Доступ запрещён"},
+		},
+		{
+			"wrapped_form_err",
+			func(c *gin.Context) {
+				Error(c, fmt.Errorf("validate: %w", dopErrs.FormErr{}.WithField("name", dopErrs.Err("required"))))
+			},
+			"fr",
+			http.StatusBadRequest,
+			dopTypes.ErrRep{
+				ErrorCode:     "form_validate",
+				Fields:        map[string]string{"name": "required"},
+				Message:       "Проверьте введённые данные",
+				FieldMessages: map[string]string{"name": "Обязательное поле"},
+			},
+		},
+		{
+			"external",
+			func(c *gin.Context) {
+				Error(c, fmt.Errorf("get profile: %w", httpc.NewError(&httpc.ResponseSt{StatusCode: 401}, dopErrs.NotAuthorized)))
+			},
+			"en",
+			http.StatusBadGateway,
+			dopTypes.ErrRep{ErrorCode: "bad_status_code", Message: "External service error"},
+		},
+		{
+			"external_unavailable",
+			func(c *gin.Context) { Error(c, httpc.NewError(&httpc.ResponseSt{}, dopErrs.ServiceNA)) },
+			"en",
+			http.StatusServiceUnavailable,
+			dopTypes.ErrRep{ErrorCode: "service_not_available", Message: "Service is temporarily unavailable"},
+		},
+		{
+			"external_transport",
+			func(c *gin.Context) { Error(c, httpc.NewError(&httpc.ResponseSt{}, errors.New("connection refused"))) },
+			"",
+			http.StatusInternalServerError,
+			dopTypes.ErrRep{},
+		},
+		{
+			"panic",
+			func(c *gin.Context) { panic("oops") },
+			"",
+			http.StatusInternalServerError,
+			dopTypes.ErrRep{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, rep := doRecovery(t, tt.handler, tt.lang)
+			require.Equal(t, tt.status, status)
+			require.Equal(t, tt.rep, rep)
+		})
+	}
+}
+
+func TestMwRecoveryBindErr(t *testing.T) {
+	status, rep := doRecovery(t, func(c *gin.Context) {
+		obj := struct {
+			Name string `json:"name"`
+		}{}
+		_ = c.BindJSON(&obj)
+	}, "")
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "bad_json", rep.ErrorCode)
+	require.NotEmpty(t, rep.Desc)
+}
+
+func TestMwRecoveryProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(MwRecoveryWithOptions(zap.New("info", true), RecoveryOptionsSt{
+		Problem:         true,
+		ProblemTypeBase: "https://example.com/errors/",
+	}))
+	r.GET("/users/:id", func(c *gin.Context) {
+		Error(c, dopErrs.ObjectNotFound.WithDesc("user"))
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("oops")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Accept-Language", "en")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	rep := dopTypes.ProblemRep{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep))
+	require.Equal(t, dopTypes.ProblemRep{
+		Type:      "https://example.com/errors/object_not_found",
+		Title:     "Object not found",
+		Status:    http.StatusNotFound,
+		Detail:    "user",
+		Instance:  "/users/1",
+		ErrorCode: "object_not_found",
+	}, rep)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	rep = dopTypes.ProblemRep{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep))
+	require.Equal(t, "about:blank", rep.Type)
+	require.Equal(t, "Internal Server Error", rep.Title)
+}
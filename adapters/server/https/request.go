@@ -0,0 +1,119 @@
+package https
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/supernova0730/dop/adapters/logger"
+)
+
+const (
+	RequestIdHeader = "X-Request-ID"
+
+	requestIdMaxLen = 128
+)
+
+const requestIdCtxKey = "dop_request_id"
+
+// MwRequestId takes request id from X-Request-ID header or generates new one,
+// stores it in gin and request contexts (see logger.LiteWithCtx) and sets response header.
+// It must be registered before MwRecovery and MwAccessLog
+func MwRequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIdHeader)
+		if !isValidRequestId(requestId) {
+			requestId = newRequestId()
+		}
+
+		c.Set(requestIdCtxKey, requestId)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestId(c.Request.Context(), requestId))
+		c.Header(RequestIdHeader, requestId)
+
+		c.Next()
+	}
+}
+
+// GetRequestId returns empty string if MwRequestId is not used
+func GetRequestId(c *gin.Context) string {
+	return c.GetString(requestIdCtxKey)
+}
+
+// GetLogger returns lg, which adds request id to every call
+func GetLogger(c *gin.Context, lg logger.Lite) logger.Lite {
+	return logger.LiteWithCtx(lg, c.Request.Context())
+}
+
+type AccessLogOptionsSt struct {
+	SkipPaths []string                    // e.g. "/healthz", "/readyz"
+	UserId    func(c *gin.Context) string // value of "user_id" field, default GetAuthUserId
+}
+
+// MwAccessLog logs every request after it is handled: info for statuses < 500, warn for others
+func MwAccessLog(lg logger.Lite, opts AccessLogOptionsSt) gin.HandlerFunc {
+	skipPaths := make(map[string]bool, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		skipPaths[p] = true
+	}
+
+	if opts.UserId == nil {
+		opts.UserId = GetAuthUserId
+	}
+
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		if skipPaths[path] {
+			return
+		}
+
+		status := c.Writer.Status()
+
+		args := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", status,
+			"latency_ms", time.Since(startTime).Milliseconds(),
+			"size", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+
+		if userId := opts.UserId(c); userId != "" {
+			args = append(args, "user_id", userId)
+		}
+
+		cLg := GetLogger(c, lg)
+
+		if status >= 500 {
+			cLg.Warnw("Request", args...)
+		} else {
+			cLg.Infow("Request", args...)
+		}
+	}
+}
+
+// isValidRequestId accepts printable ascii without spaces, to keep logs and headers safe
+func isValidRequestId(v string) bool {
+	if v == "" || len(v) > requestIdMaxLen {
+		return false
+	}
+
+	for i := 0; i < len(v); i++ {
+		if v[i] <= ' ' || v[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
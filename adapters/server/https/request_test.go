@@ -0,0 +1,108 @@
+package https
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type logRecordSt struct {
+	level string
+	msg   string
+	args  map[string]any
+}
+
+type testLoggerSt struct {
+	records []logRecordSt
+	mu      sync.Mutex
+}
+
+func (l *testLoggerSt) add(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec := logRecordSt{level: level, msg: msg, args: map[string]any{}}
+	for i := 0; i+1 < len(args); i += 2 {
+		rec.args[args[i].(string)] = args[i+1]
+	}
+
+	l.records = append(l.records, rec)
+}
+
+func (l *testLoggerSt) Infow(msg string, args ...any) { l.add("info", msg, args) }
+
+func (l *testLoggerSt) Warnw(msg string, args ...any) { l.add("warn", msg, args) }
+
+func (l *testLoggerSt) Errorw(msg string, err any, args ...any) { l.add("error", msg, args) }
+
+func TestMwRequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lg := &testLoggerSt{}
+
+	r := gin.New()
+	r.Use(MwRequestId(), MwAccessLog(lg, AccessLogOptionsSt{
+		SkipPaths: []string{"/healthz"},
+		UserId: func(c *gin.Context) string {
+			return c.GetHeader("X-User")
+		},
+	}), MwRecovery(lg, nil))
+
+	r.GET("/ok", func(c *gin.Context) {
+		GetLogger(c, lg).Infow("Handler", "request_id_in_ctx", GetRequestId(c))
+		c.String(http.StatusOK, "hello")
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	r.GET("/healthz", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v[0])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// incoming id
+	w := do("/ok", http.Header{RequestIdHeader: {"abc-123"}, "X-User": {"42"}})
+	require.Equal(t, "abc-123", w.Header().Get(RequestIdHeader))
+	require.Len(t, lg.records, 2)
+	require.Equal(t, "Handler", lg.records[0].msg)
+	require.Equal(t, "abc-123", lg.records[0].args["request_id"])
+	require.Equal(t, "abc-123", lg.records[0].args["request_id_in_ctx"])
+	require.Equal(t, "info", lg.records[1].level)
+	require.Equal(t, "abc-123", lg.records[1].args["request_id"])
+	require.Equal(t, http.StatusOK, lg.records[1].args["status"])
+	require.Equal(t, 5, lg.records[1].args["size"])
+	require.Equal(t, "/ok", lg.records[1].args["path"])
+	require.Equal(t, "42", lg.records[1].args["user_id"])
+
+	// generated id, invalid incoming one is replaced
+	lg.records = nil
+	w = do("/panic", http.Header{RequestIdHeader: {"bad id"}})
+	requestId := w.Header().Get(RequestIdHeader)
+	require.Len(t, requestId, 32)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Len(t, lg.records, 2)
+	require.Equal(t, "error", lg.records[0].level)
+	require.Equal(t, requestId, lg.records[0].args["request_id"])
+	require.Equal(t, "warn", lg.records[1].level)
+	require.Equal(t, requestId, lg.records[1].args["request_id"])
+	require.NotContains(t, lg.records[1].args, "user_id")
+
+	// skipped path
+	lg.records = nil
+	w = do("/healthz", nil)
+	require.NotEmpty(t, w.Header().Get(RequestIdHeader))
+	require.Empty(t, lg.records)
+}
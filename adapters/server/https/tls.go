@@ -0,0 +1,179 @@
+package https
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/supernova0730/dop/adapters/logger"
+)
+
+func (s *St) newTLSConfig(opts *TLSOptionsSt) (*tls.Config, error) {
+	reloader, err := newCertReloader(s.lg, opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tls.Config{
+		MinVersion:     opts.MinVersion,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if result.MinVersion == 0 {
+		result.MinVersion = tls.VersionTLS12
+	}
+
+	if opts.ClientCAFile != "" {
+		caData, err := ioutil.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		result.ClientCAs = x509.NewCertPool()
+		if !result.ClientCAs.AppendCertsFromPEM(caData) {
+			return nil, errors.New("no certificates in client_ca_file")
+		}
+
+		result.ClientAuth = opts.ClientAuth
+		if result.ClientAuth == tls.NoClientCert {
+			result.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	} else {
+		result.ClientAuth = opts.ClientAuth
+	}
+
+	if opts.ReloadInterval > 0 {
+		reloader.start(opts.ReloadInterval)
+		s.reloader = reloader
+	}
+
+	return result, nil
+}
+
+func (s *St) stopReloader() {
+	if s.reloader != nil {
+		s.reloader.stop()
+		s.reloader = nil
+	}
+}
+
+// certReloaderSt serves certificate, which is reloaded when cert or key file is modified
+type certReloaderSt struct {
+	lg       logger.Lite
+	certFile string
+	keyFile  string
+
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+	mu       sync.RWMutex
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+func newCertReloader(lg logger.Lite, certFile, keyFile string) (*certReloaderSt, error) {
+	r := &certReloaderSt{
+		lg:       lg,
+		certFile: certFile,
+		keyFile:  keyFile,
+		stopChan: make(chan struct{}),
+	}
+
+	err := r.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloaderSt) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+func (r *certReloaderSt) reload() error {
+	modTimes, err := r.getModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *certReloaderSt) getModTimes() ([2]time.Time, error) {
+	var result [2]time.Time
+
+	for i, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return result, err
+		}
+		result[i] = info.ModTime()
+	}
+
+	return result, nil
+}
+
+func (r *certReloaderSt) start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopChan:
+				return
+			case <-ticker.C:
+				r.reloadIfModified()
+			}
+		}
+	}()
+}
+
+func (r *certReloaderSt) reloadIfModified() {
+	modTimes, err := r.getModTimes()
+	if err != nil {
+		r.lg.Errorw("Fail to check tls certificate files", err, "cert_file", r.certFile)
+		return
+	}
+
+	r.mu.RLock()
+	modified := modTimes != r.modTimes
+	r.mu.RUnlock()
+
+	if !modified {
+		return
+	}
+
+	// the old certificate is kept on failure, e.g. when only one of files is updated yet
+	err = r.reload()
+	if err != nil {
+		r.lg.Errorw("Fail to reload tls certificate", err, "cert_file", r.certFile)
+		return
+	}
+
+	r.lg.Infow("Tls certificate reloaded", "cert_file", r.certFile)
+}
+
+func (r *certReloaderSt) stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
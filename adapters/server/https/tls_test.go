@@ -0,0 +1,200 @@
+package https
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+)
+
+type testCertSt struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPem []byte
+	keyPem  []byte
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCertSt, usage x509.ExtKeyUsage) *testCertSt {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	parentCert, parentKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCertSt{
+		cert:    cert,
+		key:     key,
+		certPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPem:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func writeTestFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestStartTLS(t *testing.T) {
+	lg := zap.New("info", true)
+	dir := t.TempDir()
+
+	ca := newTestCert(t, "ca", nil, x509.ExtKeyUsageAny)
+	serverCert := newTestCert(t, "server1", ca, x509.ExtKeyUsageServerAuth)
+	clientCert := newTestCert(t, "client", ca, x509.ExtKeyUsageClientAuth)
+
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	writeTestFile(t, certFile, serverCert.certPem, time.Now())
+	writeTestFile(t, keyFile, serverCert.keyPem, time.Now())
+	writeTestFile(t, caFile, ca.certPem, time.Now())
+
+	s, err := StartWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}), lg, OptionsSt{
+		Addr: "127.0.0.1:0",
+		TLS: &TLSOptionsSt{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: caFile,
+		},
+	})
+	require.NoError(t, err)
+	defer s.Shutdown(time.Second)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	clientTLSCert, err := tls.X509KeyPair(clientCert.certPem, clientCert.keyPem)
+	require.NoError(t, err)
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+	}
+
+	rep, err := newClient(clientTLSCert).Get("https://" + s.Addr())
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(rep.Body)
+	rep.Body.Close()
+	require.Equal(t, "client", string(body))
+	require.Equal(t, "server1", rep.TLS.PeerCertificates[0].Subject.CommonName)
+
+	// client certificate is required
+	_, err = newClient().Get("https://" + s.Addr())
+	require.Error(t, err)
+}
+
+func TestCertReloader(t *testing.T) {
+	lg := zap.New("info", true)
+	dir := t.TempDir()
+
+	ca := newTestCert(t, "ca", nil, x509.ExtKeyUsageAny)
+	cert1 := newTestCert(t, "server1", ca, x509.ExtKeyUsageServerAuth)
+	cert2 := newTestCert(t, "server2", ca, x509.ExtKeyUsageServerAuth)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ts := time.Now().Add(-time.Minute)
+	writeTestFile(t, certFile, cert1.certPem, ts)
+	writeTestFile(t, keyFile, cert1.keyPem, ts)
+
+	r, err := newCertReloader(lg, certFile, keyFile)
+	require.NoError(t, err)
+
+	servedCN := func() string {
+		cert, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+		x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return x509Cert.Subject.CommonName
+	}
+
+	require.Equal(t, "server1", servedCN())
+
+	// only cert is updated, key does not match, old certificate is kept
+	writeTestFile(t, certFile, cert2.certPem, ts.Add(time.Second))
+	r.reloadIfModified()
+	require.Equal(t, "server1", servedCN())
+
+	writeTestFile(t, keyFile, cert2.keyPem, ts.Add(time.Second))
+	r.reloadIfModified()
+	require.Equal(t, "server2", servedCN())
+}
+
+func TestStartUnixSocket(t *testing.T) {
+	lg := zap.New("info", true)
+
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+
+	s, err := StartWithOptions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}), lg, OptionsSt{UnixSocket: socketPath, WriteTimeout: time.Second})
+	require.NoError(t, err)
+
+	c := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+
+	rep, err := c.Get("http://unix/")
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(rep.Body)
+	rep.Body.Close()
+	require.Equal(t, "ok", string(body))
+
+	require.True(t, s.Shutdown(time.Second))
+
+	_, err = os.Stat(socketPath)
+	require.True(t, os.IsNotExist(err))
+
+	// regular file is not removed
+	filePath := filepath.Join(t.TempDir(), "api.conf")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte("data"), 0644))
+
+	_, err = StartWithOptions(http.NotFoundHandler(), lg, OptionsSt{UnixSocket: filePath})
+	require.Error(t, err)
+
+	data, err := ioutil.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+}
@@ -0,0 +1,120 @@
+package https
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/supernova0730/dop/dopErrs"
+	"github.com/supernova0730/dop/dopTools"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = RegisterValidators(v)
+	}
+}
+
+// RegisterValidators registers tags: phone, mail, iin (see dopTools validators).
+// It is called for gin default validator on init
+func RegisterValidators(v *validator.Validate) error {
+	validators := map[string]func(string) bool{
+		"phone": dopTools.ValidatePhone,
+		"mail":  dopTools.ValidateEmail,
+		"iin":   dopTools.ValidateIin,
+	}
+
+	for tag, fn := range validators {
+		fn := fn
+
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return fn(fl.Field().String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidationFormErr converts validator errors into dopErrs.FormErr, field names are taken from tagKey
+// ("json", "form") of obj, codes are validator tags: "required", "phone", "min" etc.
+// The second value is false if err is not validator.ValidationErrors
+func ValidationFormErr(err error, obj any, tagKey string) (dopErrs.FormErr, bool) {
+	var vErrs validator.ValidationErrors
+
+	if !errors.As(err, &vErrs) {
+		return dopErrs.FormErr{}, false
+	}
+
+	result := dopErrs.FormErr{Fields: make(map[string]dopErrs.Err, len(vErrs))}
+
+	objType := reflect.TypeOf(obj)
+
+	for _, fe := range vErrs {
+		result.Fields[fieldPath(objType, fe.StructNamespace(), tagKey)] = dopErrs.Err(fe.Tag())
+	}
+
+	return result, true
+}
+
+// fieldPath converts struct namespace ("Obj.Items[0].Name") into path of tag names ("items[0].name")
+func fieldPath(t reflect.Type, namespace string, tagKey string) string {
+	segments := strings.Split(namespace, ".")
+	if len(segments) > 1 {
+		segments = segments[1:] // root type name
+	}
+
+	result := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		name, index := segment, ""
+		if i := strings.IndexByte(segment, '['); i >= 0 {
+			name, index = segment[:i], segment[i:]
+		}
+
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		tagName := name
+
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				v := strings.Split(f.Tag.Get(tagKey), ",")[0]
+				t = f.Type
+				// fields of embedded struct are inlined
+				if f.Anonymous && v == "" && index == "" {
+					continue
+				}
+				if v != "" && v != "-" {
+					tagName = v
+				}
+			} else {
+				t = nil
+			}
+		} else {
+			t = nil
+		}
+
+		// element type for each index: "[0]", "[key]"
+		for n := strings.Count(index, "["); n > 0 && t != nil; n-- {
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				t = t.Elem()
+			default:
+				t = nil
+			}
+		}
+
+		result = append(result, tagName+index)
+	}
+
+	return strings.Join(result, ".")
+}
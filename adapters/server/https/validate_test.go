@@ -0,0 +1,89 @@
+package https
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+	"github.com/supernova0730/dop/dopTypes"
+)
+
+type testBaseSt struct {
+	Iin string `json:"iin" form:"iin" binding:"omitempty,iin"`
+}
+
+type testItemSt struct {
+	Name string `json:"item_name" binding:"required"`
+}
+
+type testFormSt struct {
+	testBaseSt
+	Phone string        `json:"phone" form:"phone" binding:"required,phone"`
+	Email string        `json:"email" form:"email_addr" binding:"omitempty,mail"`
+	Items []*testItemSt `json:"items" binding:"dive"`
+	Raw   string        `binding:"max=3"`
+}
+
+func doBind(t *testing.T, method, target string, body []byte) (int, dopTypes.ErrRep) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(MwRecovery(zap.New("info", true), nil))
+	r.Handle(method, "/", func(c *gin.Context) {
+		obj := &testFormSt{}
+		if method == http.MethodGet {
+			if !BindQuery(c, obj) {
+				return
+			}
+		} else if !BindJSON(c, obj) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set("Accept-Language", "en")
+	r.ServeHTTP(w, req)
+
+	rep := dopTypes.ErrRep{}
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep))
+	}
+
+	return w.Code, rep
+}
+
+func TestBindValidation(t *testing.T) {
+	status, rep := doBind(t, http.MethodPost, "/", []byte(`{"iin":"123","email":"bad","items":[{"item_name":"a"},{}],"Raw":"abcd"}`))
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "form_validate", rep.ErrorCode)
+	require.Equal(t, map[string]string{
+		"iin":                "iin",
+		"phone":              "required",
+		"email":              "mail",
+		"items[1].item_name": "required",
+		"Raw":                "max",
+	}, rep.Fields)
+	require.Equal(t, "Required field", rep.FieldMessages["phone"])
+
+	status, rep = doBind(t, http.MethodGet, "/?phone=123&email_addr=bad", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, map[string]string{
+		"phone":      "phone",
+		"email_addr": "mail",
+	}, rep.Fields)
+	require.Equal(t, "Invalid phone number", rep.FieldMessages["phone"])
+
+	status, rep = doBind(t, http.MethodPost, "/", []byte(`{"phone":1}`))
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "bad_json", rep.ErrorCode)
+
+	status, _ = doBind(t, http.MethodPost, "/", []byte(`{"phone":"77071234567","email":"a@b.kz"}`))
+	require.Equal(t, http.StatusOK, status)
+}
@@ -1,5 +1,10 @@
 package sms
 
+import (
+	"context"
+)
+
 type Sms interface {
 	Send(phone string, msg string) bool
+	SendCtx(ctx context.Context, phone string, msg string) bool
 }
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"sync"
@@ -33,6 +34,10 @@ func New(lg logger.Lite, testing bool) *St {
 }
 
 func (m *St) Send(phone string, msg string) bool {
+	return m.SendCtx(context.Background(), phone, msg)
+}
+
+func (m *St) SendCtx(ctx context.Context, phone string, msg string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
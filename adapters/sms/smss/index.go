@@ -1,6 +1,8 @@
 package smss
 
 import (
+	"context"
+
 	"github.com/supernova0730/dop/adapters/client/httpc"
 )
 
@@ -15,7 +17,11 @@ func New(httpc httpc.HttpC) *St {
 }
 
 func (s *St) Send(phone string, msg string) bool {
-	_, _, err := s.httpc.SendJson(SendReqSt{
+	return s.SendCtx(context.Background(), phone, msg)
+}
+
+func (s *St) SendCtx(ctx context.Context, phone string, msg string) bool {
+	_, _, err := s.httpc.SendJsonCtx(ctx, SendReqSt{
 		To:   phone,
 		Text: msg,
 		Sync: true,
@@ -1,7 +1,14 @@
 package ws
 
+import (
+	"context"
+)
+
 type Ws interface {
 	Send2User(usrId int64, data any) error
+	Send2UserCtx(ctx context.Context, usrId int64, data any) error
 	Send2Users(usrIds []int64, data any) error
+	Send2UsersCtx(ctx context.Context, usrIds []int64, data any) error
 	GetConnectionCount() (int64, error)
+	GetConnectionCountCtx(ctx context.Context) (int64, error)
 }
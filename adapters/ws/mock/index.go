@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"sync"
 )
 
@@ -24,7 +25,15 @@ func (m *St) Send2User(usrId int64, data any) error {
 	return m.Send2Users([]int64{usrId}, data)
 }
 
+func (m *St) Send2UserCtx(ctx context.Context, usrId int64, data any) error {
+	return m.Send2UsersCtx(ctx, []int64{usrId}, data)
+}
+
 func (m *St) Send2Users(usrIds []int64, data any) error {
+	return m.Send2UsersCtx(context.Background(), usrIds, data)
+}
+
+func (m *St) Send2UsersCtx(ctx context.Context, usrIds []int64, data any) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -48,6 +57,10 @@ func (m *St) GetConnectionCount() (int64, error) {
 	return 0, nil
 }
 
+func (m *St) GetConnectionCountCtx(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
 func (m *St) PullAll() []Req {
 	m.mu.Lock()
 	defer m.mu.Unlock()
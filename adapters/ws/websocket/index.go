@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"time"
 
 	"github.com/supernova0730/dop/adapters/client/httpc"
@@ -18,10 +19,18 @@ func New(httpc httpc.HttpC) *St {
 }
 
 func (p *St) Send2User(usrId int64, data any) error {
-	return p.Send2Users([]int64{usrId}, data)
+	return p.Send2UsersCtx(context.Background(), []int64{usrId}, data)
+}
+
+func (p *St) Send2UserCtx(ctx context.Context, usrId int64, data any) error {
+	return p.Send2UsersCtx(ctx, []int64{usrId}, data)
 }
 
 func (p *St) Send2Users(usrIds []int64, data any) error {
+	return p.Send2UsersCtx(context.Background(), usrIds, data)
+}
+
+func (p *St) Send2UsersCtx(ctx context.Context, usrIds []int64, data any) error {
 	if len(usrIds) == 0 {
 		return nil
 	}
@@ -31,7 +40,7 @@ func (p *St) Send2Users(usrIds []int64, data any) error {
 		Message: data,
 	}
 
-	_, _, err := p.httpc.SendJson(reqObj, httpc.OptionsSt{
+	_, _, err := p.httpc.SendJsonCtx(ctx, reqObj, httpc.OptionsSt{
 		Method:        "POST",
 		Path:          "send",
 		LogPrefix:     "Send: ",
@@ -39,24 +48,32 @@ func (p *St) Send2Users(usrIds []int64, data any) error {
 		RetryInterval: 3 * time.Second,
 	})
 	if err != nil {
-		return err
+		return httpc.WrapExternal(err)
 	}
 
 	return nil
 }
 
 func (p *St) GetConnectionCount() (int64, error) {
+	return p.GetConnectionCountCtx(context.Background())
+}
+
+func (p *St) GetConnectionCountCtx(ctx context.Context) (int64, error) {
 	repObj := ws.ConnectionCountRepSt{}
 
-	_, _, err := p.httpc.SendRecvJson(nil, &repObj, nil, httpc.OptionsSt{
-		Method:        "POST",
-		Path:          "connection_count",
-		LogPrefix:     "ConnectionCount: ",
-		RetryCount:    1,
-		RetryInterval: time.Second,
+	_, _, err := p.httpc.SendRecvJsonCtx(ctx, nil, &repObj, nil, httpc.OptionsSt{
+		Method:     "POST",
+		Path:       "connection_count",
+		LogPrefix:  "ConnectionCount: ",
+		RetryCount: 1,
+		// read-only, safe to retry
+		RetryPolicy: httpc.RetryPolicySt{
+			InitialInterval:    time.Second,
+			RetryNonIdempotent: true,
+		},
 	})
 	if err != nil {
-		return 0, err
+		return 0, httpc.WrapExternal(err)
 	}
 
 	return repObj.Value, nil
@@ -0,0 +1,108 @@
+package dopErrs
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Code
+
+// Code is a gRPC-like error code
+type Code int
+
+const (
+	CodeOK Code = iota
+	CodeCanceled
+	CodeUnknown
+	CodeInvalidArgument
+	CodeDeadlineExceeded
+	CodeNotFound
+	CodeAlreadyExists
+	CodePermissionDenied
+	CodeResourceExhausted
+	CodeFailedPrecondition
+	CodeAborted
+	CodeOutOfRange
+	CodeUnimplemented
+	CodeInternal
+	CodeUnavailable
+	CodeDataLoss
+	CodeUnauthenticated
+)
+
+var codeNames = [...]string{
+	"ok",
+	"canceled",
+	"unknown",
+	"invalid_argument",
+	"deadline_exceeded",
+	"not_found",
+	"already_exists",
+	"permission_denied",
+	"resource_exhausted",
+	"failed_precondition",
+	"aborted",
+	"out_of_range",
+	"unimplemented",
+	"internal",
+	"unavailable",
+	"data_loss",
+	"unauthenticated",
+}
+
+func (c Code) String() string {
+	if c < 0 || int(c) >= len(codeNames) {
+		return "unknown"
+	}
+
+	return codeNames[c]
+}
+
+// Registry
+
+type InfoSt struct {
+	HttpStatus int
+	Code       Code
+}
+
+// DefaultInfo is used for errors, which are not registered
+var DefaultInfo = InfoSt{
+	HttpStatus: http.StatusBadRequest,
+	Code:       CodeInvalidArgument,
+}
+
+var (
+	registry = map[Err]InfoSt{
+		NotAuthorized:    {HttpStatus: http.StatusUnauthorized, Code: CodeUnauthenticated},
+		PermissionDenied: {HttpStatus: http.StatusForbidden, Code: CodePermissionDenied},
+		ObjectNotFound:   {HttpStatus: http.StatusNotFound, Code: CodeNotFound},
+		NotImplemented:   {HttpStatus: http.StatusNotImplemented, Code: CodeUnimplemented},
+		ServiceNA:        {HttpStatus: http.StatusServiceUnavailable, Code: CodeUnavailable},
+		BadStatusCode:    {HttpStatus: http.StatusBadGateway, Code: CodeUnavailable},
+	}
+	registryMu sync.RWMutex
+)
+
+// Register sets http status and code for err, it overrides previous registration.
+// Usually called on init of project, which defines its own errors
+func Register(err Err, httpStatus int, code Code) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[err] = InfoSt{
+		HttpStatus: httpStatus,
+		Code:       code,
+	}
+}
+
+// GetInfo returns registered info of err or DefaultInfo
+func GetInfo(err Err) InfoSt {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if info, ok := registry[err]; ok {
+		return info
+	}
+
+	return DefaultInfo
+}
@@ -1,5 +1,9 @@
 package dopErrs
 
+import (
+	"errors"
+)
+
 // Err
 
 type Err string
@@ -8,15 +12,75 @@ func (e Err) Error() string {
 	return string(e)
 }
 
+func (e Err) HttpStatus() int {
+	return GetInfo(e).HttpStatus
+}
+
+func (e Err) Code() Code {
+	return GetInfo(e).Code
+}
+
+func (e Err) WithDesc(desc string) ErrWithDesc {
+	return ErrWithDesc{Err: e, Desc: desc}
+}
+
+// WithCause wraps cause, which is not shown to client, but matched by errors.Is and errors.As
+func (e Err) WithCause(cause error) ErrWithDesc {
+	return ErrWithDesc{Err: e, Cause: cause}
+}
+
 // ErrWithDesc
 
 type ErrWithDesc struct {
-	Err  Err
-	Desc string
+	Err   Err
+	Desc  string
+	Cause error
 }
 
 func (e ErrWithDesc) Error() string {
-	return e.Err.Error() + ", desc:" + e.Desc
+	result := e.Err.Error() + ", desc:" + e.Desc
+
+	if e.Cause != nil {
+		result += ", cause:" + e.Cause.Error()
+	}
+
+	return result
+}
+
+func (e ErrWithDesc) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the cause matches target, e.Err is matched through Unwrap
+func (e ErrWithDesc) Is(target error) bool {
+	return e.Cause != nil && errors.Is(e.Cause, target)
+}
+
+// As finds target in e.Err first, so dopErrs.Err of cause does not replace e.Err, then in the cause
+func (e ErrWithDesc) As(target any) bool {
+	if errors.As(e.Err, target) {
+		return true
+	}
+
+	return e.Cause != nil && errors.As(e.Cause, target)
+}
+
+func (e ErrWithDesc) WithDesc(desc string) ErrWithDesc {
+	e.Desc = desc
+	return e
+}
+
+func (e ErrWithDesc) WithCause(cause error) ErrWithDesc {
+	e.Cause = cause
+	return e
+}
+
+func (e ErrWithDesc) HttpStatus() int {
+	return e.Err.HttpStatus()
+}
+
+func (e ErrWithDesc) Code() Code {
+	return e.Err.Code()
 }
 
 // FormErr
@@ -29,6 +93,46 @@ func (e FormErr) Error() string {
 	return FormValidate.Error()
 }
 
+func (e FormErr) Unwrap() error {
+	return FormValidate
+}
+
+// WithField returns copy of e with field error added
+func (e FormErr) WithField(name string, err Err) FormErr {
+	fields := make(map[string]Err, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+
+	fields[name] = err
+
+	return FormErr{Fields: fields}
+}
+
+func (e FormErr) HttpStatus() int {
+	return FormValidate.HttpStatus()
+}
+
+func (e FormErr) Code() Code {
+	return FormValidate.Code()
+}
+
+// HttpError is implemented by Err, ErrWithDesc and FormErr
+
+type HttpError interface {
+	error
+	HttpStatus() int
+	Code() Code
+}
+
+// ExternalError is implemented by errors of external services (e.g. *httpc.ErrorSt), it unwraps to the original error.
+// Their client errors (401, 403 etc.) are not errors of the caller, see https.GetErrRep
+
+type ExternalError interface {
+	error
+	External() bool
+}
+
 // errors
 
 const (
@@ -0,0 +1,88 @@
+package dopErrs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestHttpStatus(t *testing.T) {
+	const customErr = Err("custom_err")
+
+	tests := []struct {
+		err    HttpError
+		status int
+		code   Code
+	}{
+		{BadJson, http.StatusBadRequest, CodeInvalidArgument},
+		{NotAuthorized, http.StatusUnauthorized, CodeUnauthenticated},
+		{ErrWithDesc{Err: PermissionDenied}, http.StatusForbidden, CodePermissionDenied},
+		{FormErr{}, http.StatusBadRequest, CodeInvalidArgument},
+		{BadStatusCode, http.StatusBadGateway, CodeUnavailable},
+		{customErr, http.StatusBadRequest, CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			require.Equal(t, tt.status, tt.err.HttpStatus())
+			require.Equal(t, tt.code, tt.err.Code())
+		})
+	}
+
+	Register(customErr, http.StatusConflict, CodeAlreadyExists)
+	require.Equal(t, http.StatusConflict, customErr.HttpStatus())
+	require.Equal(t, "already_exists", ErrWithDesc{Err: customErr}.Code().String())
+}
+
+func TestUnwrap(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	err := fmt.Errorf("fetch user: %w", ServiceNA.WithDesc("users").WithCause(cause))
+
+	require.ErrorIs(t, err, ServiceNA)
+	require.ErrorIs(t, err, cause)
+	require.NotErrorIs(t, err, NotAuthorized)
+	require.Equal(t, "fetch user: service_not_available, desc:users, cause:connection refused", err.Error())
+
+	var descErr ErrWithDesc
+	require.ErrorAs(t, err, &descErr)
+	require.Equal(t, "users", descErr.Desc)
+	require.Equal(t, http.StatusServiceUnavailable, descErr.HttpStatus())
+
+	pathErr := &fs.PathError{Op: "open", Path: "/tmp/x", Err: fs.ErrNotExist}
+	var pe *fs.PathError
+	require.ErrorAs(t, fmt.Errorf("%w", NoRows.WithCause(pathErr)), &pe)
+	require.Same(t, pathErr, pe)
+
+	// Err of cause does not replace own Err
+	var dErr Err
+	require.ErrorAs(t, ServiceNA.WithCause(NotAuthorized), &dErr)
+	require.Equal(t, ServiceNA, dErr)
+
+	formErr := FormErr{}.WithField("phone", Err("bad_phone"))
+	formErr2 := formErr.WithField("email", Err("bad_email"))
+	require.Len(t, formErr.Fields, 1)
+	require.Len(t, formErr2.Fields, 2)
+	require.ErrorIs(t, fmt.Errorf("%w", formErr2), FormValidate)
+}
+
+func TestMessage(t *testing.T) {
+	require.Equal(t, language.Kazakh, MatchLang("kk-KZ"))
+	require.Equal(t, language.English, MatchLang("de;q=1, en-GB;q=0.8"))
+	require.Equal(t, DefaultLang, MatchLang("fr"))
+	require.Equal(t, DefaultLang, MatchLang("bad header;;"))
+
+	require.Equal(t, "Object not found", Message(ObjectNotFound, language.English))
+
+	RegisterMessages(language.Russian, map[Err]string{"custom_msg_err": "Своя ошибка"})
+	require.Equal(t, "Своя ошибка", Message("custom_msg_err", language.English))
+	require.Equal(t, "", Message("unknown_err", language.English))
+
+	RegisterMessages(language.Uzbek, map[Err]string{ObjectNotFound: "Obyekt topilmadi"})
+	require.Equal(t, language.Uzbek, MatchLang("uz"))
+	require.Equal(t, "Obyekt topilmadi", Message(ObjectNotFound, language.Uzbek))
+}
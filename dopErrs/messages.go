@@ -0,0 +1,158 @@
+package dopErrs
+
+import (
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLang is used if requested language is not supported or has no message for error
+var DefaultLang = language.Russian
+
+var (
+	messages = map[language.Tag]map[Err]string{
+		language.Russian: {
+			NoRows:            "Данные не найдены",
+			BadColumnName:     "Неверное имя колонки",
+			BadJson:           "Некорректные данные запроса",
+			BadQueryParams:    "Некорректные параметры запроса",
+			ServiceNA:         "Сервис временно недоступен",
+			NotImplemented:    "Не реализовано",
+			NotAuthorized:     "Требуется авторизация",
+			PermissionDenied:  "Доступ запрещён",
+			ObjectNotFound:    "Объект не найден",
+			IncorrectPageSize: "Некорректный размер страницы",
+			BadStatusCode:     "Ошибка внешнего сервиса",
+			FormValidate:      "Проверьте введённые данные",
+
+			// form field errors, codes are validator tags
+			Err("required"): "Обязательное поле",
+			Err("phone"):    "Некорректный номер телефона",
+			Err("mail"):     "Некорректный адрес электронной почты",
+			Err("email"):    "Некорректный адрес электронной почты",
+			Err("iin"):      "Некорректный ИИН",
+			Err("min"):      "Значение меньше допустимого",
+			Err("max"):      "Значение больше допустимого",
+			Err("len"):      "Некорректная длина",
+			Err("oneof"):    "Недопустимое значение",
+		},
+		language.Kazakh: {
+			NoRows:            "Деректер табылмады",
+			BadColumnName:     "Баған атауы қате",
+			BadJson:           "Сұраныс деректері қате",
+			BadQueryParams:    "Сұраныс параметрлері қате",
+			ServiceNA:         "Қызмет уақытша қолжетімсіз",
+			NotImplemented:    "Іске асырылмаған",
+			NotAuthorized:     "Авторизация қажет",
+			PermissionDenied:  "Қол жеткізуге тыйым салынған",
+			ObjectNotFound:    "Нысан табылмады",
+			IncorrectPageSize: "Бет өлшемі қате",
+			BadStatusCode:     "Сыртқы қызмет қатесі",
+			FormValidate:      "Енгізілген деректерді тексеріңіз",
+
+			// form field errors, codes are validator tags
+			Err("required"): "Міндетті өріс",
+			Err("phone"):    "Телефон нөмірі қате",
+			Err("mail"):     "Электрондық пошта мекенжайы қате",
+			Err("email"):    "Электрондық пошта мекенжайы қате",
+			Err("iin"):      "ЖСН қате",
+			Err("min"):      "Мән рұқсат етілгеннен аз",
+			Err("max"):      "Мән рұқсат етілгеннен көп",
+			Err("len"):      "Ұзындығы қате",
+			Err("oneof"):    "Рұқсат етілмеген мән",
+		},
+		language.English: {
+			NoRows:            "No data found",
+			BadColumnName:     "Invalid column name",
+			BadJson:           "Invalid request data",
+			BadQueryParams:    "Invalid query parameters",
+			ServiceNA:         "Service is temporarily unavailable",
+			NotImplemented:    "Not implemented",
+			NotAuthorized:     "Authorization required",
+			PermissionDenied:  "Permission denied",
+			ObjectNotFound:    "Object not found",
+			IncorrectPageSize: "Incorrect page size",
+			BadStatusCode:     "External service error",
+			FormValidate:      "Check the entered data",
+
+			// form field errors, codes are validator tags
+			Err("required"): "Required field",
+			Err("phone"):    "Invalid phone number",
+			Err("mail"):     "Invalid email address",
+			Err("email"):    "Invalid email address",
+			Err("iin"):      "Invalid IIN",
+			Err("min"):      "Value is less than allowed",
+			Err("max"):      "Value is greater than allowed",
+			Err("len"):      "Invalid length",
+			Err("oneof"):    "Value is not allowed",
+		},
+	}
+	supportedLangs []language.Tag
+	langMatcher    language.Matcher
+	messagesMu     sync.RWMutex
+)
+
+func init() {
+	refreshLangMatcher()
+}
+
+// RegisterMessages adds messages (for errors and form field errors) in lang, new language becomes supported
+func RegisterMessages(lang language.Tag, msgs map[Err]string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+
+	langMessages, ok := messages[lang]
+	if !ok {
+		langMessages = map[Err]string{}
+		messages[lang] = langMessages
+	}
+
+	for k, v := range msgs {
+		langMessages[k] = v
+	}
+
+	refreshLangMatcher()
+}
+
+// refreshLangMatcher must be called under lock
+func refreshLangMatcher() {
+	supportedLangs = []language.Tag{DefaultLang}
+
+	for tag := range messages {
+		if tag != DefaultLang {
+			supportedLangs = append(supportedLangs, tag)
+		}
+	}
+
+	langMatcher = language.NewMatcher(supportedLangs)
+}
+
+// MatchLang returns supported language for Accept-Language header value, DefaultLang if nothing matches
+func MatchLang(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLang
+	}
+
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	_, idx, confidence := langMatcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLang
+	}
+
+	return supportedLangs[idx]
+}
+
+// Message returns message for err in lang, falls back to DefaultLang, empty if not found
+func Message(err Err, lang language.Tag) string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	if msg, ok := messages[lang][err]; ok {
+		return msg
+	}
+
+	return messages[DefaultLang][err]
+}
@@ -0,0 +1,310 @@
+package dopLifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supernova0730/dop/adapters/logger"
+	"github.com/supernova0730/dop/dopTools"
+)
+
+const (
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+type ComponentSt struct {
+	Name      string
+	DependsOn []string // components started before and stopped after this one
+
+	Start func(ctx context.Context) error // optional
+	Stop  func(ctx context.Context) error // optional, ctx is done on deadline
+	// Wait is optional, an error received from it (e.g. https.St.Wait) triggers shutdown in Run
+	Wait func() <-chan error
+
+	StopTimeout time.Duration // limited by OptionsSt.ShutdownTimeout, 0 - no own limit
+}
+
+type OptionsSt struct {
+	StartTimeout    time.Duration // 0 - no timeout
+	ShutdownTimeout time.Duration // global deadline for all components, default DefaultShutdownTimeout
+}
+
+// ComponentErrorSt reports component, which failed to start or stop
+type ComponentErrorSt struct {
+	Component string
+	Stage     string // "start", "stop" or "run"
+	Err       error
+}
+
+func (e *ComponentErrorSt) Error() string {
+	return e.Stage + " " + e.Component + ": " + e.Err.Error()
+}
+
+func (e *ComponentErrorSt) Unwrap() error {
+	return e.Err
+}
+
+// StopErrorSt contains all components failed to stop
+type StopErrorSt struct {
+	Errs []*ComponentErrorSt
+}
+
+func (e *StopErrorSt) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+type St struct {
+	lg   logger.Lite
+	opts OptionsSt
+
+	components []*ComponentSt
+	started    []*ComponentSt
+	mu         sync.Mutex
+}
+
+func New(lg logger.Lite, opts OptionsSt) *St {
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	return &St{
+		lg:   lg,
+		opts: opts,
+	}
+}
+
+func (l *St) Add(components ...ComponentSt) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range components {
+		l.components = append(l.components, &components[i])
+	}
+}
+
+// Start starts components in dependency order. If one fails, already started ones are stopped
+func (l *St) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ordered, err := sortComponents(l.components)
+	if err != nil {
+		return err
+	}
+
+	if l.opts.StartTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.opts.StartTimeout)
+		defer cancel()
+	}
+
+	for _, c := range ordered {
+		if c.Start != nil {
+			if err = callCtx(ctx, c.Start); err != nil {
+				l.lg.Errorw("Fail to start component", err, "component", c.Name)
+
+				startErr := &ComponentErrorSt{Component: c.Name, Stage: "start", Err: err}
+
+				if stopErr := l.stop(); stopErr != nil {
+					return &StopErrorSt{Errs: append([]*ComponentErrorSt{startErr}, stopErr.Errs...)}
+				}
+
+				return startErr
+			}
+		}
+
+		l.started = append(l.started, c)
+	}
+
+	return nil
+}
+
+// Stop stops started components in reverse order within ShutdownTimeout,
+// all components are stopped even if some fail, the error is *StopErrorSt
+func (l *St) Stop() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.stop(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *St) stop() *StopErrorSt {
+	ctx, cancel := context.WithTimeout(context.Background(), l.opts.ShutdownTimeout)
+	defer cancel()
+
+	var errs []*ComponentErrorSt
+
+	for i := len(l.started) - 1; i >= 0; i-- {
+		c := l.started[i]
+
+		if c.Stop == nil {
+			continue
+		}
+
+		cCtx, cCancel := ctx, context.CancelFunc(func() {})
+		if c.StopTimeout > 0 {
+			cCtx, cCancel = context.WithTimeout(ctx, c.StopTimeout)
+		}
+
+		err := callCtx(cCtx, c.Stop)
+		cCancel()
+
+		if err != nil {
+			l.lg.Errorw("Fail to stop component", err, "component", c.Name)
+			errs = append(errs, &ComponentErrorSt{Component: c.Name, Stage: "stop", Err: err})
+		}
+	}
+
+	l.started = nil
+
+	if len(errs) > 0 {
+		return &StopErrorSt{Errs: errs}
+	}
+
+	return nil
+}
+
+// Run starts components and blocks until ctx is done, stop signal is received or
+// any component reports error through Wait, then stops components
+func (l *St) Run(ctx context.Context) error {
+	err := l.Start(ctx)
+	if err != nil {
+		return err
+	}
+
+	failChan := make(chan *ComponentErrorSt, 1)
+
+	l.mu.Lock()
+	for _, c := range l.started {
+		if c.Wait == nil {
+			continue
+		}
+		go func(name string, ch <-chan error) {
+			for err := range ch {
+				if err != nil {
+					select {
+					case failChan <- &ComponentErrorSt{Component: name, Stage: "run", Err: err}:
+					default:
+					}
+					return
+				}
+			}
+		}(c.Name, c.Wait())
+	}
+	l.mu.Unlock()
+
+	var runErr *ComponentErrorSt
+
+	select {
+	case <-ctx.Done():
+	case <-dopTools.StopSignal():
+	case runErr = <-failChan:
+		l.lg.Errorw("Component failed", runErr.Err, "component", runErr.Component)
+	}
+
+	l.lg.Infow("Shutting down")
+
+	err = l.Stop()
+
+	switch {
+	case runErr != nil && err != nil:
+		return &StopErrorSt{Errs: append([]*ComponentErrorSt{runErr}, err.(*StopErrorSt).Errs...)}
+	case runErr != nil:
+		return runErr
+	default:
+		return err
+	}
+}
+
+// callCtx returns ctx error if fn does not return in time
+func callCtx(ctx context.Context, fn func(ctx context.Context) error) error {
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- fn(ctx)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sortComponents(components []*ComponentSt) ([]*ComponentSt, error) {
+	byName := make(map[string]*ComponentSt, len(components))
+
+	for _, c := range components {
+		if _, ok := byName[c.Name]; ok {
+			return nil, errors.New("duplicate component: " + c.Name)
+		}
+		byName[c.Name] = c
+	}
+
+	result := make([]*ComponentSt, 0, len(components))
+	state := make(map[string]int, len(components)) // 1 - visiting, 2 - done
+
+	var visit func(c *ComponentSt, path []string) error
+	visit = func(c *ComponentSt, path []string) error {
+		switch state[c.Name] {
+		case 1:
+			return errors.New("dependency cycle: " + strings.Join(append(path, c.Name), " -> "))
+		case 2:
+			return nil
+		}
+
+		state[c.Name] = 1
+
+		for _, depName := range c.DependsOn {
+			dep, ok := byName[depName]
+			if !ok {
+				return errors.New("unknown dependency " + depName + " of component " + c.Name)
+			}
+			if err := visit(dep, append(path, c.Name)); err != nil {
+				return err
+			}
+		}
+
+		state[c.Name] = 2
+		result = append(result, c)
+
+		return nil
+	}
+
+	// registration order is kept for independent components
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// StopFn adapts close functions without error, e.g. pg.St.Close, zap.St.Sync
+func StopFn(fn func()) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		fn()
+		return nil
+	}
+}
+
+// StopErrFn adapts close functions, e.g. redis.St.Close
+func StopErrFn(fn func() error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return fn()
+	}
+}
@@ -0,0 +1,146 @@
+package dopLifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/supernova0730/dop/adapters/logger/zap"
+)
+
+type testLogSt struct {
+	events []string
+	mu     sync.Mutex
+}
+
+func (o *testLogSt) component(name string, deps []string, startErr, stopErr error) ComponentSt {
+	return ComponentSt{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(ctx context.Context) error {
+			o.add("start " + name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			o.add("stop " + name)
+			return stopErr
+		},
+	}
+}
+
+func (o *testLogSt) add(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.events = append(o.events, event)
+}
+
+func TestLifecycle(t *testing.T) {
+	lg := zap.New("info", true)
+
+	log := &testLogSt{}
+
+	l := New(lg, OptionsSt{})
+	l.Add(
+		log.component("server", []string{"pg", "redis"}, nil, nil),
+		log.component("pg", nil, nil, nil),
+		log.component("redis", nil, nil, nil),
+		log.component("worker", []string{"pg"}, nil, nil),
+	)
+
+	require.NoError(t, l.Start(context.Background()))
+	require.NoError(t, l.Stop())
+	require.Equal(t, []string{
+		"start pg", "start redis", "start server", "start worker",
+		"stop worker", "stop server", "stop redis", "stop pg",
+	}, log.events)
+
+	// failed start stops started components
+	log = &testLogSt{}
+	startErr := errors.New("connection refused")
+
+	l = New(lg, OptionsSt{})
+	l.Add(
+		log.component("pg", nil, nil, nil),
+		log.component("redis", nil, startErr, nil),
+		log.component("server", []string{"redis"}, nil, nil),
+	)
+
+	err := l.Start(context.Background())
+	require.ErrorIs(t, err, startErr)
+	require.Equal(t, "start redis: connection refused", err.Error())
+	require.Equal(t, []string{"start pg", "start redis", "stop pg"}, log.events)
+
+	// bad dependencies
+	l = New(lg, OptionsSt{})
+	l.Add(log.component("a", []string{"b"}, nil, nil), log.component("b", []string{"a"}, nil, nil))
+	require.EqualError(t, l.Start(context.Background()), "dependency cycle: a -> b -> a")
+
+	l = New(lg, OptionsSt{})
+	l.Add(log.component("a", []string{"c"}, nil, nil))
+	require.Error(t, l.Start(context.Background()))
+}
+
+func TestLifecycleStopDeadline(t *testing.T) {
+	lg := zap.New("info", true)
+
+	log := &testLogSt{}
+	stopErr := errors.New("flush failed")
+
+	l := New(lg, OptionsSt{ShutdownTimeout: time.Second})
+	l.Add(
+		log.component("logger", nil, nil, stopErr),
+		ComponentSt{
+			Name:        "hanging",
+			DependsOn:   []string{"logger"},
+			Stop:        func(ctx context.Context) error { select {} },
+			StopTimeout: 10 * time.Millisecond,
+		},
+	)
+
+	require.NoError(t, l.Start(context.Background()))
+
+	err := l.Stop()
+
+	stopErrs := &StopErrorSt{}
+	require.ErrorAs(t, err, &stopErrs)
+	require.Len(t, stopErrs.Errs, 2)
+	require.Equal(t, "hanging", stopErrs.Errs[0].Component)
+	require.ErrorIs(t, stopErrs.Errs[0], context.DeadlineExceeded)
+	require.Equal(t, "logger", stopErrs.Errs[1].Component)
+	require.ErrorIs(t, stopErrs.Errs[1], stopErr)
+}
+
+func TestLifecycleRun(t *testing.T) {
+	lg := zap.New("info", true)
+
+	log := &testLogSt{}
+	runErr := errors.New("listen failed")
+	errChan := make(chan error, 1)
+
+	server := log.component("server", nil, nil, nil)
+	server.Wait = func() <-chan error { return errChan }
+
+	l := New(lg, OptionsSt{})
+	l.Add(server)
+
+	errChan <- runErr
+
+	err := l.Run(context.Background())
+	require.ErrorIs(t, err, runErr)
+	require.Equal(t, []string{"start server", "stop server"}, log.events)
+
+	// context is done
+	log.events = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	l = New(lg, OptionsSt{})
+	l.Add(log.component("pg", nil, nil, nil))
+	require.NoError(t, l.Run(ctx))
+	require.Equal(t, []string{"start pg", "stop pg"}, log.events)
+}
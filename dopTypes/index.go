@@ -5,9 +5,23 @@ import (
 )
 
 type ErrRep struct {
-	ErrorCode string            `json:"error_code"`
-	Desc      string            `json:"desc,omitempty"`
-	Fields    map[string]string `json:"fields,omitempty"`
+	ErrorCode     string            `json:"error_code"`
+	Desc          string            `json:"desc,omitempty"`
+	Fields        map[string]string `json:"fields,omitempty"`
+	Message       string            `json:"message,omitempty"`
+	FieldMessages map[string]string `json:"field_messages,omitempty"`
+}
+
+// ProblemRep is RFC 7807 problem details, ErrRep fields are extension members
+type ProblemRep struct {
+	Type          string            `json:"type"`
+	Title         string            `json:"title"`
+	Status        int               `json:"status"`
+	Detail        string            `json:"detail,omitempty"`
+	Instance      string            `json:"instance,omitempty"`
+	ErrorCode     string            `json:"error_code,omitempty"`
+	Fields        map[string]string `json:"fields,omitempty"`
+	FieldMessages map[string]string `json:"field_messages,omitempty"`
 }
 
 type ListParams struct {